@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"gorm/config"
+	"gorm/fixtures"
+	"gorm/presenter"
+	"gorm/scenario"
+
+	"gorm.io/gorm"
+)
+
+// 退出码
+const (
+	exitOK      = 0
+	exitFailure = 1 // 运行失败，例如数据库连接失败
+	exitUsage   = 2 // 命令行参数错误
+)
+
+// command 子命令
+type command struct {
+	name    string
+	summary string
+	run     func(loader *config.Loader, args []string) int
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"run", "执行一个或多个示例（run --all 执行全部）", runCommand},
+		{"list", "列出所有示例", listCommand},
+		{"migrate", "数据库迁移（up、down、status）", migrateCommand},
+		{"health", "检查数据库连接并输出连接池统计", healthCommand},
+		{"ledger", "核对账户余额与复式记账分录（ledger verify）", ledgerCommand},
+		{"statement", "查询账户对账单，支持分页和 CSV、JSON 导出", statementCommand},
+		{"transfers", "按发起人、渠道、账户或时间查询转账记录", transfersCommand},
+		{"schedule", "定时转账：创建、查询、取消和执行到期的期次", scheduleCommand},
+	}
+}
+
+// execute 解析全局参数并分发到子命令，返回进程退出码
+func execute(args []string, stderr io.Writer) int {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	loader := config.NewLoader(fs)
+	fs.Usage = func() { printUsage(fs) }
+
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	name := fs.Arg(0)
+	for _, c := range commands {
+		if c.name == name {
+			return c.run(loader, fs.Args()[1:])
+		}
+	}
+	fmt.Fprintf(stderr, "未知命令: %s\n\n", name)
+	fs.Usage()
+	return exitUsage
+}
+
+func printUsage(fs *flag.FlagSet) {
+	out := fs.Output()
+	fmt.Fprintln(out, "用法: app [全局参数] <命令> [参数]")
+	fmt.Fprintln(out, "\n命令:")
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-10s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(out, "\n全局参数:")
+	fs.PrintDefaults()
+}
+
+// listCommand 列出所有示例
+func listCommand(_ *config.Loader, args []string) int {
+	if len(args) > 0 {
+		fmt.Fprintf(os.Stderr, "list 不接受参数: %s\n", strings.Join(args, " "))
+		return exitUsage
+	}
+	for _, s := range scenario.All() {
+		fmt.Printf("%-10s %s\n", s.Name(), s.Description())
+	}
+	return exitOK
+}
+
+// runCommand 执行指定的示例
+func runCommand(loader *config.Loader, args []string) int {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	all := fs.Bool("all", false, "按名称顺序执行全部示例")
+	format := fs.String("format", string(presenter.FormatText), "查询结果的输出格式（text、json、table）")
+	fixtureSet := fs.String("fixtures", fixtures.SetDemo, "加载的测试数据集合（"+strings.Join(fixtures.Sets(), "、")+"）")
+	reset := fs.Bool("reset", false, "加载测试数据前清空示例使用的表（会删除已有数据）")
+	for _, s := range scenario.All() {
+		if b, ok := s.(scenario.FlagBinder); ok {
+			b.BindFlags(fs)
+		}
+	}
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: app run [参数] <示例>... | app run --all [参数]")
+		fmt.Fprintln(fs.Output(), "\n参数:")
+		fs.PrintDefaults()
+	}
+
+	names, err := parseInterspersed(fs, args)
+	if err != nil {
+		return exitUsage
+	}
+
+	outFormat, err := presenter.ParseFormat(*format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	if !slices.Contains(fixtures.Sets(), *fixtureSet) {
+		fmt.Fprintf(os.Stderr, "未知的测试数据集合: %s\n", *fixtureSet)
+		return exitUsage
+	}
+
+	var selected []scenario.Scenario
+	switch {
+	case *all && len(names) > 0:
+		fmt.Fprintln(os.Stderr, "--all 不能与示例名称同时使用")
+		return exitUsage
+	case *all:
+		selected = scenario.All()
+	case len(names) == 0:
+		fs.Usage()
+		return exitUsage
+	default:
+		for _, name := range names {
+			s, ok := scenario.Lookup(name)
+			if !ok {
+				fmt.Fprintf(os.Stderr, "未知示例: %s（可选: %s）\n", name, strings.Join(scenario.Names(), ", "))
+				return exitUsage
+			}
+			selected = append(selected, s)
+		}
+	}
+
+	db, code := openDatabase(loader)
+	if code != exitOK {
+		return code
+	}
+
+	ctx := presenter.NewContext(context.Background(), presenter.New(outFormat, os.Stdout))
+	ctx = fixtures.NewContext(ctx, fixtures.Options{Set: *fixtureSet, Reset: *reset})
+	failed := 0
+	for _, s := range selected {
+		if err := scenario.Execute(ctx, db, s); err != nil {
+			fmt.Fprintf(os.Stderr, "示例 %s 执行失败: %v\n", s.Name(), err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d/%d 个示例执行失败\n", failed, len(selected))
+		return exitFailure
+	}
+	return exitOK
+}
+
+// openDatabase 加载配置并连接数据库，失败时输出原因并返回对应的退出码
+func openDatabase(loader *config.Loader) (*gorm.DB, int) {
+	cfg, err := loader.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "加载配置失败: %v\n", err)
+		return nil, exitUsage
+	}
+
+	db, err := connectDatabase(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "数据库连接失败: %v\n", err)
+		return nil, exitFailure
+	}
+	return db, exitOK
+}
+
+// parseInterspersed 解析参数，允许参数和位置参数交替出现，例如 run transfer -amount 200
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
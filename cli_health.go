@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"gorm/config"
+	"gorm/database"
+	"gorm/presenter"
+)
+
+// healthResult 健康检查结果
+type healthResult struct {
+	Status  string        `json:"status" label:"状态"`
+	Latency time.Duration `json:"latency" label:"耗时"`
+	Error   string        `json:"error,omitempty" label:"错误"`
+}
+
+// healthCommand 检查数据库连接并输出连接池统计：health [-timeout D] [-format F]
+func healthCommand(loader *config.Loader, args []string) int {
+	fs := flag.NewFlagSet("health", flag.ContinueOnError)
+	timeout := fs.Duration("timeout", 5*time.Second, "Ping 的超时时间")
+	format := fs.String("format", string(presenter.FormatText), "输出格式（text、json、table）")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: app health [-timeout D] [-format F]")
+		fmt.Fprintln(fs.Output(), "\n参数:")
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return exitUsage
+	}
+	if len(positional) != 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	outFormat, err := presenter.ParseFormat(*format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	db, code := openDatabase(loader)
+	if code != exitOK {
+		return code
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	start := time.Now()
+	checkErr := database.HealthCheck(ctx, db)
+	result := healthResult{Status: "正常", Latency: time.Since(start)}
+	if checkErr != nil {
+		result.Status = "异常"
+		result.Error = checkErr.Error()
+	}
+
+	stats, err := database.Stats(db)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取连接池统计失败: %v\n", err)
+		return exitFailure
+	}
+
+	p := presenter.New(outFormat, os.Stdout)
+	if err := p.Print("健康检查", result); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	if err := p.Print("连接池", stats); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	if checkErr != nil {
+		return exitFailure
+	}
+	return exitOK
+}
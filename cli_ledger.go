@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"gorm/config"
+	"gorm/gormSqlTwo"
+	"gorm/presenter"
+)
+
+// ledgerCommand 账本操作：ledger verify [-format F]
+func ledgerCommand(loader *config.Loader, args []string) int {
+	fs := flag.NewFlagSet("ledger", flag.ContinueOnError)
+	format := fs.String("format", string(presenter.FormatTable), "输出格式（text、json、table）")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: app ledger verify [-format F]")
+		fmt.Fprintln(fs.Output(), "\n参数:")
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return exitUsage
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+	outFormat, err := presenter.ParseFormat(*format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if action := positional[0]; action != "verify" {
+		fmt.Fprintf(os.Stderr, "未知的账本操作: %s\n", action)
+		fs.Usage()
+		return exitUsage
+	}
+
+	db, code := openDatabase(loader)
+	if code != exitOK {
+		return code
+	}
+
+	discrepancies, err := gormSqlTwo.VerifyLedger(db)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "核对账本失败: %v\n", err)
+		return exitFailure
+	}
+	if err := presenter.New(outFormat, os.Stdout).Print("余额与分录不一致的账户", discrepancies); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	if len(discrepancies) > 0 {
+		fmt.Fprintf(os.Stderr, "%d 个账户的余额与分录不一致\n", len(discrepancies))
+		return exitFailure
+	}
+	return exitOK
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"gorm/config"
+	"gorm/migrate"
+	"gorm/presenter"
+)
+
+// migrateCommand 执行数据库迁移：migrate up | migrate down [-steps N] | migrate status
+func migrateCommand(loader *config.Loader, args []string) int {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	steps := fs.Int("steps", 1, "down: 回滚的迁移数量")
+	format := fs.String("format", string(presenter.FormatTable), "status: 输出格式（text、json、table）")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: app migrate up | app migrate down [-steps N] | app migrate status [-format F]")
+		fmt.Fprintln(fs.Output(), "\n参数:")
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return exitUsage
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+	outFormat, err := presenter.ParseFormat(*format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	action := positional[0]
+	if action != "up" && action != "down" && action != "status" {
+		fmt.Fprintf(os.Stderr, "未知的迁移操作: %s\n", action)
+		fs.Usage()
+		return exitUsage
+	}
+
+	db, code := openDatabase(loader)
+	if code != exitOK {
+		return code
+	}
+	ctx := context.Background()
+
+	switch action {
+	case "up":
+		done, err := migrate.Up(ctx, db)
+		for _, m := range done {
+			fmt.Printf("已执行迁移 %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "迁移失败: %v\n", err)
+			return exitFailure
+		}
+		if len(done) == 0 {
+			fmt.Println("没有需要执行的迁移")
+		}
+	case "down":
+		done, err := migrate.Down(ctx, db, *steps)
+		for _, m := range done {
+			fmt.Printf("已回滚迁移 %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "回滚失败: %v\n", err)
+			return exitFailure
+		}
+		if len(done) == 0 {
+			fmt.Println("没有可回滚的迁移")
+		}
+	case "status":
+		statuses, err := migrate.Statuses(ctx, db)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "查询迁移状态失败: %v\n", err)
+			return exitFailure
+		}
+		if err := presenter.New(outFormat, os.Stdout).Print("迁移状态", statuses); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailure
+		}
+		for _, st := range statuses {
+			if st.Modified {
+				fmt.Fprintf(os.Stderr, "迁移 %04d_%s 的脚本在执行后被修改\n", st.Version, st.Name)
+				return exitFailure
+			}
+		}
+	}
+	return exitOK
+}
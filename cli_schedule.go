@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	"gorm/config"
+	"gorm/gormSqlTwo"
+	"gorm/money"
+	"gorm/presenter"
+
+	"gorm.io/gorm"
+)
+
+// scheduleCommand 定时转账：
+//
+//	schedule add -from A -to B -amount X -schedule S [-start T] [-end T] [-max-catch-up N] [-memo M] [-initiator I]
+//	schedule list | schedule runs <ID> | schedule cancel <ID>
+//	schedule run [-watch D]
+func scheduleCommand(loader *config.Loader, args []string) int {
+	fs := flag.NewFlagSet("schedule", flag.ContinueOnError)
+	from := fs.Uint("from", 0, "add: 转出账户ID")
+	to := fs.Uint("to", 0, "add: 转入账户ID")
+	var amount money.Money
+	fs.Var(&amount, "amount", "add: 每期转账金额，最多两位小数")
+	spec := fs.String("schedule", "", "add: 执行计划（UTC），五段 cron 表达式（分 时 日 月 星期）或 @every 24h、@daily、@monthly 等")
+	start := fs.String("start", "", "add: 第一期的执行时间，2006-01-02 或 RFC 3339 格式；默认按执行计划从当前时间起算")
+	end := fs.String("end", "", "add: 结束时间（含），2006-01-02 或 RFC 3339 格式")
+	maxCatchUp := fs.Int("max-catch-up", 0, "add: 停机后最多补执行最近的几期，更早的期次跳过；0 表示全部补执行")
+	memo := fs.String("memo", "", "add: 备注")
+	initiator := fs.String("initiator", "", "add: 发起人")
+	watch := fs.Duration("watch", 0, "run: 每隔多久检查一次到期的定时转账，0 表示只执行一次")
+	format := fs.String("format", string(presenter.FormatTable), "list、runs、run: 输出格式（text、json、table）")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: app schedule add -from A -to B -amount X -schedule S [-start T] [-end T] [-max-catch-up N] [-memo M] [-initiator I]")
+		fmt.Fprintln(fs.Output(), "      app schedule list | app schedule runs <ID> | app schedule cancel <ID>")
+		fmt.Fprintln(fs.Output(), "      app schedule run [-watch D]")
+		fmt.Fprintln(fs.Output(), "\n参数:")
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return exitUsage
+	}
+	if len(positional) == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	outFormat, err := presenter.ParseFormat(*format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	action := positional[0]
+	var id uint64
+	switch action {
+	case "add", "list", "run":
+		if len(positional) != 1 {
+			fs.Usage()
+			return exitUsage
+		}
+	case "runs", "cancel":
+		if len(positional) != 2 {
+			fs.Usage()
+			return exitUsage
+		}
+		if id, err = strconv.ParseUint(positional[1], 10, 64); err != nil || id == 0 {
+			fmt.Fprintf(os.Stderr, "无效的定时转账ID: %s\n", positional[1])
+			return exitUsage
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "未知的定时转账操作: %s\n", action)
+		fs.Usage()
+		return exitUsage
+	}
+
+	st := gormSqlTwo.ScheduledTransfer{
+		FromAccountID: *from,
+		ToAccountID:   *to,
+		Amount:        amount,
+		Schedule:      *spec,
+		MaxCatchUp:    *maxCatchUp,
+		Memo:          *memo,
+		InitiatorID:   *initiator,
+	}
+	if action == "add" {
+		if st.NextRunAt, err = parseTime(*start); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+		endTime, err := parseTime(*end)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+		if !endTime.IsZero() {
+			st.EndAt = &endTime
+		}
+	}
+
+	db, code := openDatabase(loader)
+	if code != exitOK {
+		return code
+	}
+	p := presenter.New(outFormat, os.Stdout)
+
+	switch action {
+	case "add":
+		created, err := gormSqlTwo.CreateScheduledTransfer(db, st)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "创建定时转账失败: %v\n", err)
+			return exitFailure
+		}
+		fmt.Printf("已创建定时转账 %d，第一期 %s\n", created.ID, created.NextRunAt.Format(time.RFC3339))
+		return exitOK
+	case "cancel":
+		if err := gormSqlTwo.CancelScheduledTransfer(db, uint(id)); err != nil {
+			fmt.Fprintf(os.Stderr, "取消定时转账失败: %v\n", err)
+			return exitFailure
+		}
+		fmt.Printf("已取消定时转账 %d\n", id)
+		return exitOK
+	case "list":
+		list, err := gormSqlTwo.ScheduledTransfers(db)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailure
+		}
+		if err := p.Print("定时转账", list); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailure
+		}
+		return exitOK
+	case "runs":
+		runs, err := gormSqlTwo.ScheduledTransferRuns(db, uint(id))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailure
+		}
+		if err := p.Print(fmt.Sprintf("定时转账 %d 的执行记录", id), runs); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailure
+		}
+		return exitOK
+	}
+
+	if *watch <= 0 {
+		return runDueTransfers(db, p)
+	}
+	// 持续运行，收到中断信号后处理完当前这一轮再退出；重启后从数据库记录的下一期继续
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ticker := time.NewTicker(*watch)
+	defer ticker.Stop()
+	for {
+		runDueTransfers(db, p)
+		select {
+		case <-ctx.Done():
+			return exitOK
+		case <-ticker.C:
+		}
+	}
+}
+
+// runDueTransfers 执行一轮到期的定时转账并输出本轮执行的期次
+func runDueTransfers(db *gorm.DB, p *presenter.Printer) int {
+	runs, err := gormSqlTwo.RunDueTransfers(db, time.Now())
+	code := exitOK
+	if len(runs) > 0 {
+		if printErr := p.Print("定时转账执行结果", runs); printErr != nil {
+			fmt.Fprintln(os.Stderr, printErr)
+			code = exitFailure
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		code = exitFailure
+	}
+	return code
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm/config"
+	"gorm/gormSqlTwo"
+	"gorm/presenter"
+)
+
+// formatCSV statement 命令额外支持的 CSV 导出格式
+const formatCSV = "csv"
+
+// statementCommand 账户对账单：statement [-from D] [-to D] [-cursor C] [-all] [-format F] <账户ID>
+func statementCommand(loader *config.Loader, args []string) int {
+	fs := flag.NewFlagSet("statement", flag.ContinueOnError)
+	from := fs.String("from", "", "起始时间（含），2006-01-02 或 RFC 3339 格式")
+	to := fs.String("to", "", "截止时间（不含），2006-01-02 或 RFC 3339 格式")
+	cursor := fs.String("cursor", "", "上一页返回的分页游标")
+	all := fs.Bool("all", false, "读取期间内的全部明细，不分页（csv 格式总是读取全部）")
+	format := fs.String("format", string(presenter.FormatTable), "输出格式（text、json、table、csv）")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: app statement [-from D] [-to D] [-cursor C] [-all] [-format F] <账户ID>")
+		fmt.Fprintln(fs.Output(), "\n参数:")
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return exitUsage
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+	accountID, err := strconv.ParseUint(positional[0], 10, 64)
+	if err != nil || accountID == 0 {
+		fmt.Fprintf(os.Stderr, "无效的账户ID: %s\n", positional[0])
+		return exitUsage
+	}
+	fromTime, err := parseTime(*from)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	toTime, err := parseTime(*to)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	var outFormat presenter.Format
+	if *format != formatCSV {
+		if outFormat, err = presenter.ParseFormat(*format); err != nil {
+			fmt.Fprintf(os.Stderr, "不支持的输出格式 %q（可选: text、json、table、csv）\n", *format)
+			return exitUsage
+		}
+	}
+
+	db, code := openDatabase(loader)
+	if code != exitOK {
+		return code
+	}
+
+	var st *gormSqlTwo.Statement
+	if *all || *format == formatCSV {
+		st, err = gormSqlTwo.FullAccountStatement(db, uint(accountID), fromTime, toTime)
+	} else {
+		st, err = gormSqlTwo.AccountStatement(db, uint(accountID), fromTime, toTime, *cursor)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "查询对账单失败: %v\n", err)
+		return exitFailure
+	}
+
+	if *format == formatCSV {
+		err = st.WriteCSV(os.Stdout)
+	} else {
+		err = printStatement(outFormat, st)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// printStatement 输出对账单；表格格式不展示嵌套的切片，明细单独输出一张表
+func printStatement(format presenter.Format, st *gormSqlTwo.Statement) error {
+	p := presenter.New(format, os.Stdout)
+	title := fmt.Sprintf("账户%d 对账单", st.AccountID)
+	if err := p.Print(title, st); err != nil {
+		return err
+	}
+	if format == presenter.FormatTable {
+		return p.Print("明细", st.Lines)
+	}
+	return nil
+}
+
+// parseTime 解析 2006-01-02（UTC 零点）或 RFC 3339 格式的时间，空字符串表示不限制
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("无效的时间 %q（格式: 2006-01-02 或 RFC 3339）", s)
+	}
+	return t, nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"gorm/config"
+	"gorm/database"
+	"gorm/gormSqlTwo"
+	"gorm/money"
+)
+
+// setupCLIDatabase 在临时目录中创建 SQLite 数据库，写入两个账户和一笔转账，返回 -dsn 参数和转出账户
+func setupCLIDatabase(t *testing.T) (string, uint) {
+	t.Helper()
+	dsn := "file:" + filepath.Join(t.TempDir(), "cli.db") + "?_foreign_keys=on"
+	db, err := database.Open(context.Background(), &config.Config{Profile: "test", Driver: config.DriverSQLite, DSN: dsn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+
+	if err := (&gormSqlTwo.Scenario{}).Setup(context.Background(), db); err != nil {
+		t.Fatal(err)
+	}
+	a, err := gormSqlTwo.OpenAccount(db, money.CNY, money.FromUnits(500))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := gormSqlTwo.OpenAccount(db, money.CNY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gormSqlTwo.Transfer(db, gormSqlTwo.TransferRequest{FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.FromUnits(100), InitiatorID: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	return dsn, a.ID
+}
+
+// runCLI 执行命令，返回标准输出的内容和退出码
+// 命令直接写 os.Stdout，执行期间把它替换为临时文件。
+func runCLI(t *testing.T, args ...string) (string, int) {
+	t.Helper()
+	out, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	stdout := os.Stdout
+	os.Stdout = out
+	code := execute(args, io.Discard)
+	os.Stdout = stdout
+
+	data, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data), code
+}
+
+func TestExportOutputIsClean(t *testing.T) {
+	dsn, accountID := setupCLIDatabase(t)
+	global := []string{"-driver", "sqlite", "-dsn", dsn, "-sql-log", "silent"}
+	id := strconv.FormatUint(uint64(accountID), 10)
+
+	out, code := runCLI(t, append(global, "statement", "-format", "csv", id)...)
+	if code != exitOK {
+		t.Fatalf("statement -format csv 退出码 = %d", code)
+	}
+	records, err := csv.NewReader(bytes.NewBufferString(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("导出的 CSV 无法解析: %v\n%s", err, out)
+	}
+	// 表头、期初余额、开户、转账、期末余额各一行
+	if len(records) != 5 || records[0][0] != "时间" {
+		t.Errorf("CSV 有 %d 行, 期望以表头开始的 5 行:\n%s", len(records), out)
+	}
+
+	for _, args := range [][]string{
+		{"statement", "-format", "json", id},
+		{"transfers", "-format", "json", "-initiator", "alice"},
+	} {
+		out, code := runCLI(t, append(global, args...)...)
+		if code != exitOK {
+			t.Fatalf("%v 退出码 = %d", args, code)
+		}
+		var v any
+		if err := json.Unmarshal([]byte(out), &v); err != nil {
+			t.Errorf("%v 的输出不是合法的 JSON: %v\n%s", args, err, out)
+		}
+	}
+}
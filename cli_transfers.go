@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"gorm/config"
+	"gorm/gormSqlTwo"
+	"gorm/presenter"
+)
+
+// transfersCommand 查询转账记录：transfers [-initiator X] [-channel C] [-account N] [-status S] [-from D] [-to D] [-limit N] [-format F]
+func transfersCommand(loader *config.Loader, args []string) int {
+	fs := flag.NewFlagSet("transfers", flag.ContinueOnError)
+	initiator := fs.String("initiator", "", "发起人")
+	channel := fs.String("channel", "", "渠道（api、batch、admin、scheduled）")
+	account := fs.Uint("account", 0, "转出或转入账户ID")
+	status := fs.String("status", "", "状态（pending、completed、failed、reversed）")
+	from := fs.String("from", "", "起始受理时间（含），2006-01-02 或 RFC 3339 格式")
+	to := fs.String("to", "", "截止受理时间（不含），2006-01-02 或 RFC 3339 格式")
+	limit := fs.Int("limit", gormSqlTwo.DefaultTransferQueryLimit, "最多返回的记录数")
+	format := fs.String("format", string(presenter.FormatTable), "输出格式（text、json、table）")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: app transfers [-initiator X] [-channel C] [-account N] [-status S] [-from D] [-to D] [-limit N] [-format F]")
+		fmt.Fprintln(fs.Output(), "\n参数:")
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return exitUsage
+	}
+	if len(positional) != 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	q := gormSqlTwo.TransferQuery{
+		InitiatorID: *initiator,
+		Channel:     gormSqlTwo.Channel(*channel),
+		AccountID:   *account,
+		Status:      gormSqlTwo.TransferStatus(*status),
+		Limit:       *limit,
+	}
+	if q.Channel != "" && !q.Channel.Valid() {
+		fmt.Fprintf(os.Stderr, "不支持的转账渠道 %q（可选: api、batch、admin、scheduled）\n", *channel)
+		return exitUsage
+	}
+	if q.From, err = parseTime(*from); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if q.To, err = parseTime(*to); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	outFormat, err := presenter.ParseFormat(*format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	db, code := openDatabase(loader)
+	if code != exitOK {
+		return code
+	}
+
+	transactions, err := gormSqlTwo.FindTransfers(db, q)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return exitFailure
+	}
+	if err := presenter.New(outFormat, os.Stdout).Print("转账记录", transactions); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	return exitOK
+}
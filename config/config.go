@@ -0,0 +1,316 @@
+// Package config 从命令行参数、环境变量、配置文件和内置 profile 加载数据库配置
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"gorm/sqllog"
+)
+
+// 数据库配置
+// 驱动和 DSN 按以下优先级确定（高优先级覆盖低优先级）：
+//   1. 命令行参数 -driver / -dsn
+//   2. 环境变量 DB_DRIVER / DB_DSN
+//   3. 配置文件（-config 或 APP_CONFIG 指定的 JSON 文件）中的 profile
+//   4. 内置 profile（dev / test / ci）
+// profile 由 -profile 或 APP_PROFILE 指定，默认为 dev。
+// 连接池、启动重试和 SQL 日志参数可以在 profile 中配置，也可以用命令行参数覆盖。
+// 配置文件中写出的字段和命令行中给出的参数总是生效，值为 0 也会覆盖低优先级的设置。
+
+// 支持的数据库驱动
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite"
+)
+
+// 环境变量名
+const (
+	EnvProfile = "APP_PROFILE"
+	EnvConfig  = "APP_CONFIG"
+	EnvDriver  = "DB_DRIVER"
+	EnvDSN     = "DB_DSN"
+)
+
+// DefaultProfile 未指定 profile 时使用的默认值
+const DefaultProfile = "dev"
+
+// SQL 日志的默认设置：输出失败的语句和超过 200ms 的慢查询
+const (
+	DefaultLogLevel      = "warn"
+	DefaultSlowThreshold = Duration(200 * time.Millisecond)
+)
+
+// Config 数据库连接配置
+type Config struct {
+	Profile string `json:"-"`
+	Driver  string `json:"driver"`
+	DSN     string `json:"dsn"`
+	Pool    Pool   `json:"pool"`
+	// ConnectTimeout 启动时连接失败后按指数退避重试的最长时间，0 表示只尝试一次
+	ConnectTimeout Duration `json:"connect_timeout"`
+	Log            Log      `json:"log"`
+}
+
+// Log SQL 日志参数，日志以 JSON 格式输出到标准错误
+type Log struct {
+	// Level 日志级别：silent、error、warn、info
+	Level string `json:"level"`
+	// SlowThreshold 慢查询阈值，0 表示不检测慢查询
+	SlowThreshold Duration `json:"slow_threshold"`
+	// RedactParams 隐藏 SQL 中字符串类型的参数值（密码、邮箱等）
+	RedactParams bool `json:"redact_params"`
+}
+
+// Pool 连接池参数，0 表示使用 database/sql 的默认值
+type Pool struct {
+	MaxOpenConns    int      `json:"max_open_conns"`
+	MaxIdleConns    int      `json:"max_idle_conns"`
+	ConnMaxLifetime Duration `json:"conn_max_lifetime"`
+}
+
+// Duration 配置文件中以字符串表示的时长，例如 "30s"、"5m"
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("时长应为字符串（例如 \"30s\"）: %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// File 配置文件结构
+// profile 保留原始 JSON，加载时只覆盖其中出现的字段。
+type File struct {
+	Profiles map[string]json.RawMessage `json:"profiles"`
+}
+
+// builtinProfiles 内置 profile：dev 使用本地 MySQL，test 和 ci 使用 SQLite，无需数据库服务
+var builtinProfiles = map[string]Config{
+	"dev": {
+		Driver: DriverMySQL,
+		DSN:    "root:123456@tcp(localhost:3306)/grom?charset=utf8mb4&parseTime=True&loc=UTC",
+		Pool: Pool{
+			MaxOpenConns:    10,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: Duration(30 * time.Minute),
+		},
+		// docker-compose 中 MySQL 启动较慢，等待其就绪
+		ConnectTimeout: Duration(30 * time.Second),
+	},
+	"test": {
+		Driver: DriverSQLite,
+		DSN:    "file::memory:?cache=shared&_foreign_keys=on",
+	},
+	"ci": {
+		Driver: DriverSQLite,
+		// 等待其他连接释放锁，并以 BEGIN IMMEDIATE 开始事务，避免并发转账时读锁升级失败
+		DSN: "file:gorm_ci.db?_foreign_keys=on&_busy_timeout=5000&_txlock=immediate",
+	},
+}
+
+// Loader 从命令行参数、环境变量和配置文件加载配置
+type Loader struct {
+	fs             *flag.FlagSet
+	profile        string
+	file           string
+	driver         string
+	dsn            string
+	maxOpenConns   int
+	maxIdleConns   int
+	connMaxLife    time.Duration
+	connectTimeout time.Duration
+	logLevel       string
+	slowThreshold  time.Duration
+	redactParams   bool
+}
+
+// NewLoader 在 fs 上注册配置相关的命令行参数
+func NewLoader(fs *flag.FlagSet) *Loader {
+	l := &Loader{fs: fs}
+	fs.StringVar(&l.profile, "profile", "", "配置 profile（dev、test、ci 或配置文件中定义的名称），也可通过 "+EnvProfile+" 指定")
+	fs.StringVar(&l.file, "config", "", "JSON 配置文件路径，也可通过 "+EnvConfig+" 指定")
+	fs.StringVar(&l.driver, "driver", "", "数据库驱动（mysql 或 sqlite），覆盖 profile 中的设置")
+	fs.StringVar(&l.dsn, "dsn", "", "数据库 DSN，覆盖 profile 中的设置")
+	fs.IntVar(&l.maxOpenConns, "max-open-conns", 0, "最大打开连接数，覆盖 profile 中的设置")
+	fs.IntVar(&l.maxIdleConns, "max-idle-conns", 0, "最大空闲连接数，覆盖 profile 中的设置")
+	fs.DurationVar(&l.connMaxLife, "conn-max-lifetime", 0, "连接最长复用时间，覆盖 profile 中的设置")
+	fs.DurationVar(&l.connectTimeout, "connect-timeout", 0, "启动时重试连接的最长时间，覆盖 profile 中的设置")
+	fs.StringVar(&l.logLevel, "sql-log", "", "SQL 日志级别（silent、error、warn、info），默认 "+DefaultLogLevel)
+	fs.DurationVar(&l.slowThreshold, "slow-threshold", 0, "慢查询阈值，默认 "+time.Duration(DefaultSlowThreshold).String())
+	fs.BoolVar(&l.redactParams, "redact-params", false, "在 SQL 日志中隐藏字符串参数的值")
+	return l
+}
+
+// Load 按优先级合并各来源的配置
+func (l *Loader) Load() (*Config, error) {
+	name := firstNonEmpty(l.profile, os.Getenv(EnvProfile), DefaultProfile)
+
+	defaults := Config{Log: Log{Level: DefaultLogLevel, SlowThreshold: DefaultSlowThreshold}}
+	profiles := make(map[string]Config, len(builtinProfiles))
+	for k, v := range builtinProfiles {
+		profiles[k] = merge(defaults, v)
+	}
+
+	if path := firstNonEmpty(l.file, os.Getenv(EnvConfig)); path != "" {
+		f, err := ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for k, raw := range f.Profiles {
+			cfg, ok := profiles[k]
+			if !ok {
+				cfg = defaults
+			}
+			// 在已有设置上解码，只覆盖文件中出现的字段
+			if err := json.Unmarshal(raw, &cfg); err != nil {
+				return nil, fmt.Errorf("解析配置文件 %s 中的 profile %q 失败: %w", path, k, err)
+			}
+			profiles[k] = cfg
+		}
+	}
+
+	cfg, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("未知的 profile %q（可选: %v）", name, profileNames(profiles))
+	}
+	cfg.Profile = name
+	cfg = merge(cfg, Config{Driver: os.Getenv(EnvDriver), DSN: os.Getenv(EnvDSN)})
+	l.applyFlags(&cfg)
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// applyFlags 用命令行中给出的参数覆盖 cfg，没有给出的参数不影响 cfg
+func (l *Loader) applyFlags(cfg *Config) {
+	l.fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "driver":
+			cfg.Driver = l.driver
+		case "dsn":
+			cfg.DSN = l.dsn
+		case "max-open-conns":
+			cfg.Pool.MaxOpenConns = l.maxOpenConns
+		case "max-idle-conns":
+			cfg.Pool.MaxIdleConns = l.maxIdleConns
+		case "conn-max-lifetime":
+			cfg.Pool.ConnMaxLifetime = Duration(l.connMaxLife)
+		case "connect-timeout":
+			cfg.ConnectTimeout = Duration(l.connectTimeout)
+		case "sql-log":
+			cfg.Log.Level = l.logLevel
+		case "slow-threshold":
+			cfg.Log.SlowThreshold = Duration(l.slowThreshold)
+		case "redact-params":
+			cfg.Log.RedactParams = l.redactParams
+		}
+	})
+}
+
+// ReadFile 读取 JSON 配置文件
+func ReadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("解析配置文件 %s 失败: %w", path, err)
+	}
+	return &f, nil
+}
+
+// Validate 检查配置是否完整
+func (c *Config) Validate() error {
+	switch c.Driver {
+	case DriverMySQL, DriverSQLite:
+	default:
+		return fmt.Errorf("不支持的数据库驱动 %q（可选: %s、%s）", c.Driver, DriverMySQL, DriverSQLite)
+	}
+	if c.DSN == "" {
+		return fmt.Errorf("profile %q 未配置 DSN", c.Profile)
+	}
+	p := c.Pool
+	if p.MaxOpenConns < 0 || p.MaxIdleConns < 0 || p.ConnMaxLifetime < 0 || c.ConnectTimeout < 0 {
+		return fmt.Errorf("profile %q 的连接池参数和连接超时不能为负数", c.Profile)
+	}
+	if p.MaxOpenConns > 0 && p.MaxIdleConns > p.MaxOpenConns {
+		return fmt.Errorf("profile %q 的最大空闲连接数 %d 大于最大打开连接数 %d", c.Profile, p.MaxIdleConns, p.MaxOpenConns)
+	}
+	if c.Log.Level != "" {
+		if _, err := sqllog.ParseLevel(c.Log.Level); err != nil {
+			return err
+		}
+	}
+	if c.Log.SlowThreshold < 0 {
+		return fmt.Errorf("profile %q 的慢查询阈值不能为负数", c.Profile)
+	}
+	return nil
+}
+
+// merge 用 override 中的非空字段覆盖 base，用于内置 profile 和环境变量这类无法区分零值和未设置的来源
+func merge(base, override Config) Config {
+	if override.Driver != "" {
+		base.Driver = override.Driver
+	}
+	if override.DSN != "" {
+		base.DSN = override.DSN
+	}
+	if override.Pool.MaxOpenConns != 0 {
+		base.Pool.MaxOpenConns = override.Pool.MaxOpenConns
+	}
+	if override.Pool.MaxIdleConns != 0 {
+		base.Pool.MaxIdleConns = override.Pool.MaxIdleConns
+	}
+	if override.Pool.ConnMaxLifetime != 0 {
+		base.Pool.ConnMaxLifetime = override.Pool.ConnMaxLifetime
+	}
+	if override.ConnectTimeout != 0 {
+		base.ConnectTimeout = override.ConnectTimeout
+	}
+	if override.Log.Level != "" {
+		base.Log.Level = override.Log.Level
+	}
+	if override.Log.SlowThreshold != 0 {
+		base.Log.SlowThreshold = override.Log.SlowThreshold
+	}
+	if override.Log.RedactParams {
+		base.Log.RedactParams = true
+	}
+	return base
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func profileNames(profiles map[string]Config) []string {
+	names := make([]string, 0, len(profiles))
+	for k := range profiles {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
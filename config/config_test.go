@@ -0,0 +1,220 @@
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func load(t *testing.T, args ...string) (*Config, error) {
+	t.Helper()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	l := NewLoader(fs)
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return l.Load()
+}
+
+func TestLoadBuiltinProfiles(t *testing.T) {
+	t.Setenv(EnvProfile, "")
+	t.Setenv(EnvConfig, "")
+	t.Setenv(EnvDriver, "")
+	t.Setenv(EnvDSN, "")
+
+	cfg, err := load(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Profile != DefaultProfile || cfg.Driver != DriverMySQL {
+		t.Errorf("默认配置 = %+v, 期望 dev/mysql", cfg)
+	}
+
+	cfg, err = load(t, "-profile", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Driver != DriverSQLite {
+		t.Errorf("test profile 驱动 = %s, 期望 sqlite", cfg.Driver)
+	}
+
+	if _, err := load(t, "-profile", "staging"); err == nil {
+		t.Error("未知 profile 应当报错")
+	}
+
+	// SQL 日志默认只输出失败的语句和慢查询
+	if cfg.Log.Level != DefaultLogLevel || cfg.Log.SlowThreshold != DefaultSlowThreshold {
+		t.Errorf("默认日志配置 = %+v", cfg.Log)
+	}
+	cfg, err = load(t, "-profile", "test", "-sql-log", "info", "-redact-params")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Log.Level != "info" || !cfg.Log.RedactParams || cfg.Log.SlowThreshold != DefaultSlowThreshold {
+		t.Errorf("命令行指定的日志配置 = %+v", cfg.Log)
+	}
+	if _, err := load(t, "-sql-log", "debug"); err == nil {
+		t.Error("未知的日志级别应当报错")
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	file := `{"profiles": {"staging": {"driver": "mysql", "dsn": "file-dsn"}, "test": {"dsn": "file::memory:"}}}`
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvConfig, path)
+	t.Setenv(EnvProfile, "staging")
+	t.Setenv(EnvDriver, "")
+	t.Setenv(EnvDSN, "")
+
+	// 配置文件新增 profile
+	cfg, err := load(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Profile != "staging" || cfg.DSN != "file-dsn" {
+		t.Errorf("配置文件 profile = %+v", cfg)
+	}
+
+	// 配置文件只覆盖内置 profile 的部分字段
+	cfg, err = load(t, "-profile", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Driver != DriverSQLite || cfg.DSN != "file::memory:" {
+		t.Errorf("合并后的 test profile = %+v", cfg)
+	}
+
+	// 环境变量覆盖配置文件
+	t.Setenv(EnvDSN, "env-dsn")
+	cfg, err = load(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DSN != "env-dsn" {
+		t.Errorf("DSN = %s, 期望环境变量覆盖配置文件", cfg.DSN)
+	}
+
+	// 命令行参数覆盖环境变量
+	cfg, err = load(t, "-driver", "sqlite", "-dsn", "flag-dsn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Driver != DriverSQLite || cfg.DSN != "flag-dsn" {
+		t.Errorf("配置 = %+v, 期望命令行参数覆盖环境变量", cfg)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := (&Config{Driver: "postgres", DSN: "x"}).Validate(); err == nil {
+		t.Error("不支持的驱动应当报错")
+	}
+	if err := (&Config{Driver: DriverSQLite}).Validate(); err == nil {
+		t.Error("缺少 DSN 应当报错")
+	}
+	if err := (&Config{Driver: DriverSQLite, DSN: "x", Pool: Pool{MaxOpenConns: -1}}).Validate(); err == nil {
+		t.Error("负数的连接池参数应当报错")
+	}
+}
+
+func TestLoadPool(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	file := `{"profiles": {"staging": {"driver": "mysql", "dsn": "x",
+		"pool": {"max_open_conns": 20, "max_idle_conns": 10, "conn_max_lifetime": "1h"}, "connect_timeout": "10s"}}}`
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvConfig, path)
+	t.Setenv(EnvProfile, "staging")
+	t.Setenv(EnvDriver, "")
+	t.Setenv(EnvDSN, "")
+
+	cfg, err := load(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Pool{MaxOpenConns: 20, MaxIdleConns: 10, ConnMaxLifetime: Duration(time.Hour)}
+	if cfg.Pool != want || cfg.ConnectTimeout != Duration(10*time.Second) {
+		t.Errorf("配置文件中的连接池 = %+v, 连接超时 = %v", cfg.Pool, time.Duration(cfg.ConnectTimeout))
+	}
+
+	// 命令行参数只覆盖指定的字段
+	cfg, err = load(t, "-max-open-conns", "50", "-connect-timeout", "1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want.MaxOpenConns = 50
+	if cfg.Pool != want || cfg.ConnectTimeout != Duration(time.Minute) {
+		t.Errorf("命令行覆盖后的连接池 = %+v, 连接超时 = %v", cfg.Pool, time.Duration(cfg.ConnectTimeout))
+	}
+
+	if _, err := load(t, "-max-open-conns", "5"); err == nil {
+		t.Error("最大空闲连接数大于最大打开连接数应当报错")
+	}
+}
+
+func TestDurationJSON(t *testing.T) {
+	var d Duration
+	if err := json.Unmarshal([]byte(`"1m30s"`), &d); err != nil {
+		t.Fatal(err)
+	}
+	if time.Duration(d) != 90*time.Second {
+		t.Errorf("Duration = %v, 期望 1m30s", time.Duration(d))
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `"1m30s"` {
+		t.Errorf("MarshalJSON = %s", data)
+	}
+
+	if err := json.Unmarshal([]byte(`30`), &d); err == nil {
+		t.Error("数字形式的时长应当报错")
+	}
+}
+
+func TestLoadExplicitZero(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	file := `{"profiles": {"dev": {"connect_timeout": "0s"}, "staging": {"driver": "mysql", "dsn": "x",
+		"pool": {"max_open_conns": 20}, "log": {"slow_threshold": "1s", "redact_params": true}}}}`
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvConfig, path)
+	t.Setenv(EnvProfile, "")
+	t.Setenv(EnvDriver, "")
+	t.Setenv(EnvDSN, "")
+
+	// 配置文件中写出的 0 覆盖内置 profile，没有写出的字段保持不变
+	cfg, err := load(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ConnectTimeout != 0 || cfg.Pool.MaxOpenConns != 10 || cfg.Log.SlowThreshold != DefaultSlowThreshold {
+		t.Errorf("dev profile = %+v, 期望连接超时被配置文件置为 0", cfg)
+	}
+
+	// 命令行给出的 0 和 false 覆盖配置文件
+	cfg, err = load(t, "-profile", "staging", "-slow-threshold", "0", "-max-open-conns", "0", "-redact-params=false")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Log.SlowThreshold != 0 || cfg.Pool.MaxOpenConns != 0 || cfg.Log.RedactParams {
+		t.Errorf("命令行覆盖后的配置 = %+v", cfg)
+	}
+
+	// 没有给出的参数不覆盖配置文件
+	cfg, err = load(t, "-profile", "staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Log.SlowThreshold != Duration(time.Second) || cfg.Pool.MaxOpenConns != 20 || !cfg.Log.RedactParams {
+		t.Errorf("staging profile = %+v", cfg)
+	}
+}
@@ -0,0 +1,136 @@
+// Package database 按配置打开 GORM 连接，并提供带冲突重试的事务
+package database
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"time"
+
+	"gorm/config"
+	"gorm/sqllog"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// 启动时重试连接的退避间隔：从 initialBackoff 开始每次翻倍，最长 maxBackoff
+const (
+	initialBackoff = 200 * time.Millisecond
+	maxBackoff     = 5 * time.Second
+)
+
+// Now 返回 UTC 的当前时间，Open 把它设为 GORM 的 NowFunc
+// 时间需要以 UTC 保存：SQLite 没有时间类型，时间按字符串比较，时区不同的值比较结果不正确。
+// 这要求 *gorm.DB 由 Open 创建（或同样把 NowFunc 设为 Now），自行写入或作为查询条件的时间先用 Time.UTC 转换；
+// MySQL 的 DSN 中 loc 需要为 UTC（驱动的默认值），loc=Local 时驱动把 UTC 时间按本地时区的钟面时间写入。
+func Now() time.Time {
+	return time.Now().UTC()
+}
+
+// Open 根据配置选择驱动并连接数据库，并按配置设置连接池
+// cfg.ConnectTimeout 大于 0 时，连接失败后按指数退避重试，直到超过该时长或 ctx 结束；
+// 用于 docker-compose 等数据库与程序同时启动的场景。
+func Open(ctx context.Context, cfg *config.Config) (*gorm.DB, error) {
+	db, err := connect(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Pool.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(cfg.Pool.MaxOpenConns)
+	}
+	if cfg.Pool.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(cfg.Pool.MaxIdleConns)
+	}
+	if cfg.Pool.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(time.Duration(cfg.Pool.ConnMaxLifetime))
+	}
+	return db, nil
+}
+
+// Logger 按配置创建以 JSON 格式输出到标准错误的 SQL 日志
+func Logger(cfg *config.Config) (*sqllog.Logger, error) {
+	level, err := sqllog.ParseLevel(cmp.Or(cfg.Log.Level, config.DefaultLogLevel))
+	if err != nil {
+		return nil, err
+	}
+	return sqllog.New(slog.New(slog.NewJSONHandler(os.Stderr, nil)), sqllog.Options{
+		Level:         level,
+		SlowThreshold: time.Duration(cfg.Log.SlowThreshold),
+		RedactParams:  cfg.Log.RedactParams,
+	}), nil
+}
+
+// connect 连接数据库，失败时按指数退避重试
+func connect(ctx context.Context, cfg *config.Config) (*gorm.DB, error) {
+	sqlLogger, err := Logger(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := time.Duration(cfg.ConnectTimeout)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	wait := initialBackoff
+	for attempt := 1; ; attempt++ {
+		// 每次重试使用新的 Dialector，避免复用上一次失败时的状态
+		dialector, err := Dialector(cfg)
+		if err != nil {
+			return nil, err
+		}
+		db, err := gorm.Open(dialector, &gorm.Config{Logger: sqlLogger, NowFunc: Now})
+		if err == nil {
+			return db, nil
+		}
+		closeFailed(db)
+
+		if timeout <= 0 {
+			return nil, err
+		}
+		if deadline, _ := ctx.Deadline(); time.Until(deadline) < wait {
+			return nil, fmt.Errorf("连接数据库失败（%s 内尝试 %d 次）: %w", timeout, attempt, err)
+		}
+		log.Printf("连接数据库失败，%s 后重试（第 %d 次）: %v", wait, attempt, err)
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("连接数据库失败（尝试 %d 次）: %w", attempt, err)
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, maxBackoff)
+	}
+}
+
+// closeFailed 关闭连接失败时 gorm.Open 已经创建的连接池
+func closeFailed(db *gorm.DB) {
+	if db == nil || db.ConnPool == nil {
+		return
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
+
+// Dialector 返回配置对应的 GORM 方言
+func Dialector(cfg *config.Config) (gorm.Dialector, error) {
+	switch cfg.Driver {
+	case config.DriverMySQL:
+		return mysql.Open(cfg.DSN), nil
+	case config.DriverSQLite:
+		return sqlite.Open(cfg.DSN), nil
+	default:
+		return nil, fmt.Errorf("不支持的数据库驱动: %s", cfg.Driver)
+	}
+}
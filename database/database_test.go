@@ -0,0 +1,102 @@
+package database_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm/config"
+	"gorm/database"
+	"gorm/internal/testdb"
+)
+
+func TestOpenAppliesPool(t *testing.T) {
+	cfg := &config.Config{
+		Driver: config.DriverSQLite,
+		DSN:    "file:pool?mode=memory&cache=shared",
+		Pool:   config.Pool{MaxOpenConns: 3, MaxIdleConns: 2, ConnMaxLifetime: config.Duration(time.Hour)},
+	}
+	db, err := database.Open(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	defer sqlDB.Close()
+
+	stats, err := database.Stats(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.MaxOpenConnections != 3 {
+		t.Errorf("MaxOpenConnections = %d, 期望 3", stats.MaxOpenConnections)
+	}
+}
+
+func TestOpenUsesUTC(t *testing.T) {
+	db := testdb.Open(t)
+	type record struct {
+		ID        uint
+		CreatedAt time.Time
+	}
+	if err := db.AutoMigrate(&record{}); err != nil {
+		t.Fatal(err)
+	}
+	r := record{}
+	if err := db.Create(&r).Error; err != nil {
+		t.Fatal(err)
+	}
+	// GORM 自动填写的时间与 NowFunc 一样使用 UTC
+	if loc := db.NowFunc().Location(); loc != time.UTC {
+		t.Errorf("NowFunc 时区 = %s, 期望 UTC", loc)
+	}
+	if loc := r.CreatedAt.Location(); loc != time.UTC {
+		t.Errorf("CreatedAt 时区 = %s, 期望 UTC", loc)
+	}
+}
+
+func TestOpenRetriesUntilTimeout(t *testing.T) {
+	// 端口 1 上没有服务，连接会立即被拒绝
+	cfg := &config.Config{
+		Driver:         config.DriverMySQL,
+		DSN:            "root@tcp(127.0.0.1:1)/test?timeout=100ms",
+		ConnectTimeout: config.Duration(time.Second),
+	}
+
+	start := time.Now()
+	_, err := database.Open(context.Background(), cfg)
+	elapsed := time.Since(start)
+	if err == nil {
+		t.Fatal("期望连接失败")
+	}
+	// 退避间隔 200ms、400ms 后，剩余时间不足下一次的 800ms，共尝试 3 次
+	if !strings.Contains(err.Error(), "尝试 3 次") {
+		t.Errorf("err = %v, 期望重试 3 次", err)
+	}
+	if elapsed < 600*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("重试耗时 %s, 期望约 600ms", elapsed)
+	}
+
+	// 不设置连接超时时只尝试一次
+	cfg.ConnectTimeout = 0
+	start = time.Now()
+	if _, err := database.Open(context.Background(), cfg); err == nil {
+		t.Fatal("期望连接失败")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("未设置连接超时时耗时 %s, 期望不重试", elapsed)
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	db := testdb.Open(t)
+	if err := database.HealthCheck(context.Background(), db); err != nil {
+		t.Fatalf("HealthCheck: %v", err)
+	}
+
+	sqlDB, _ := db.DB()
+	sqlDB.Close()
+	if err := database.HealthCheck(context.Background(), db); err == nil {
+		t.Error("连接关闭后 HealthCheck 应当失败")
+	}
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// HealthCheck 通过 Ping 检查数据库连接是否可用
+func HealthCheck(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return fmt.Errorf("数据库健康检查失败: %w", err)
+	}
+	return nil
+}
+
+// PoolStats 连接池统计，取自 sql.DBStats
+type PoolStats struct {
+	MaxOpenConnections int           `json:"max_open_connections" label:"最大连接数"`
+	OpenConnections    int           `json:"open_connections" label:"打开连接数"`
+	InUse              int           `json:"in_use" label:"使用中"`
+	Idle               int           `json:"idle" label:"空闲"`
+	WaitCount          int64         `json:"wait_count" label:"等待次数"`
+	WaitDuration       time.Duration `json:"wait_duration" label:"等待总时长"`
+	MaxIdleClosed      int64         `json:"max_idle_closed" label:"超出空闲数关闭"`
+	MaxIdleTimeClosed  int64         `json:"max_idle_time_closed" label:"超出空闲时间关闭"`
+	MaxLifetimeClosed  int64         `json:"max_lifetime_closed" label:"超出复用时间关闭"`
+}
+
+// Stats 返回连接池统计
+func Stats(db *gorm.DB) (*PoolStats, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	s := sqlDB.Stats()
+	return &PoolStats{
+		MaxOpenConnections: s.MaxOpenConnections,
+		OpenConnections:    s.OpenConnections,
+		InUse:              s.InUse,
+		Idle:               s.Idle,
+		WaitCount:          s.WaitCount,
+		WaitDuration:       s.WaitDuration,
+		MaxIdleClosed:      s.MaxIdleClosed,
+		MaxIdleTimeClosed:  s.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  s.MaxLifetimeClosed,
+	}, nil
+}
@@ -0,0 +1,102 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/gorm"
+)
+
+// 可以重试的 MySQL 错误码
+const (
+	mysqlErrLockDeadlock    = 1213 // ER_LOCK_DEADLOCK：死锁，事务已被回滚
+	mysqlErrLockWaitTimeout = 1205 // ER_LOCK_WAIT_TIMEOUT：等待行锁超时
+)
+
+// RetryPolicy 事务冲突时的重试策略
+// 第 n 次重试前等待 InitialBackoff×2^(n-1)（最长 MaxBackoff）的一半加上同样长度内的随机时长，
+// 避免冲突的事务以相同的节奏重试再次冲突。
+type RetryPolicy struct {
+	// MaxAttempts 最多执行的次数（包括第一次），小于 1 时按 1 处理
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy Transaction 使用的重试策略
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    5,
+	InitialBackoff: 20 * time.Millisecond,
+	MaxBackoff:     500 * time.Millisecond,
+}
+
+// IsRetryable 判断错误是否为可以重新执行整个事务的并发冲突：
+// MySQL 的死锁（1213）和锁等待超时（1205），SQLite 的 SQLITE_BUSY 和 SQLITE_LOCKED
+func IsRetryable(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlErrLockDeadlock || mysqlErr.Number == mysqlErrLockWaitTimeout
+	}
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+	return false
+}
+
+// Transaction 按 DefaultRetryPolicy 执行事务
+func Transaction(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	return DefaultRetryPolicy.Transaction(db, fn)
+}
+
+// ReadTransaction 在只读事务中执行 fn，fn 中的多次查询读到同一个快照
+// MySQL 使用 REPEATABLE READ 隔离级别，一致性快照从第一次查询开始；SQLite 的事务本身就是串行化的，
+// 使用连接默认的事务选项。db 已经处于事务中时沿用外层事务。
+func ReadTransaction(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	var opts *sql.TxOptions
+	if Dialect(db) == DialectMySQL {
+		opts = &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	}
+	return db.Transaction(fn, opts)
+}
+
+// Transaction 在事务中执行 fn，遇到 IsRetryable 的错误时回滚并重新执行整个 fn
+// fn 可能执行多次，不能依赖上一次执行留下的状态。db 已经处于事务中时只执行一次（嵌套事务使用保存点），
+// 由最外层的事务负责重试：死锁时 MySQL 回滚的是整个事务，只重试保存点没有意义。
+func (p RetryPolicy) Transaction(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	if _, inTx := db.Statement.ConnPool.(gorm.TxCommitter); inTx {
+		return db.Transaction(fn)
+	}
+
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	attempts := max(p.MaxAttempts, 1)
+	wait := p.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := db.Transaction(fn)
+		if err == nil || !IsRetryable(err) {
+			return err
+		}
+		if attempt >= attempts {
+			return fmt.Errorf("事务冲突，重试 %d 次后仍然失败: %w", attempt-1, err)
+		}
+
+		delay := wait/2 + rand.N(wait/2+1)
+		log.Printf("事务冲突，%s 后重试（第 %d 次）: %v", delay, attempt, err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("事务冲突，等待重试时取消: %w", errors.Join(err, ctx.Err()))
+		case <-time.After(delay):
+		}
+		wait = min(wait*2, p.MaxBackoff)
+	}
+}
@@ -0,0 +1,206 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm/config"
+	"gorm/database"
+	"gorm/internal/testdb"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/gorm"
+)
+
+// fastRetry 测试使用的重试策略，退避时间很短
+var fastRetry = database.RetryPolicy{MaxAttempts: 4, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+
+var errDeadlock = &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{errDeadlock, true},
+		{&mysql.MySQLError{Number: 1205}, true},
+		{fmt.Errorf("转账失败: %w", errDeadlock), true},
+		{&mysql.MySQLError{Number: 1062}, false},
+		{sqlite3.Error{Code: sqlite3.ErrBusy}, true},
+		{sqlite3.Error{Code: sqlite3.ErrLocked}, true},
+		{sqlite3.Error{Code: sqlite3.ErrConstraint}, false},
+		{gorm.ErrRecordNotFound, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := database.IsRetryable(tt.err); got != tt.want {
+			t.Errorf("IsRetryable(%v) = %v, 期望 %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+type item struct {
+	ID   uint
+	Name string
+}
+
+func setupItems(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := testdb.Open(t)
+	if err := db.AutoMigrate(&item{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func countItems(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+	var n int64
+	if err := db.Model(&item{}).Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestTransactionRetriesConflicts(t *testing.T) {
+	db := setupItems(t)
+
+	// 前两次执行在写入后遇到死锁，写入被回滚；第三次成功
+	attempts := 0
+	err := fastRetry.Transaction(db, func(tx *gorm.DB) error {
+		attempts++
+		if err := tx.Create(&item{Name: "a"}).Error; err != nil {
+			return err
+		}
+		if attempts < 3 {
+			return errDeadlock
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 3 {
+		t.Errorf("执行次数 = %d, 期望 3", attempts)
+	}
+	if n := countItems(t, db); n != 1 {
+		t.Errorf("记录数 = %d, 期望 1", n)
+	}
+}
+
+func TestTransactionGivesUp(t *testing.T) {
+	db := setupItems(t)
+
+	attempts := 0
+	err := fastRetry.Transaction(db, func(tx *gorm.DB) error {
+		attempts++
+		return errDeadlock
+	})
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) || mysqlErr.Number != 1213 {
+		t.Errorf("err = %v, 期望保留 MySQL 1213 错误", err)
+	}
+	if attempts != fastRetry.MaxAttempts {
+		t.Errorf("执行次数 = %d, 期望 %d", attempts, fastRetry.MaxAttempts)
+	}
+
+	// 其他错误不重试
+	attempts = 0
+	errOther := errors.New("业务错误")
+	err = fastRetry.Transaction(db, func(tx *gorm.DB) error {
+		attempts++
+		return errOther
+	})
+	if !errors.Is(err, errOther) || attempts != 1 {
+		t.Errorf("err = %v, 执行次数 = %d, 期望不重试", err, attempts)
+	}
+}
+
+func TestTransactionNestedDoesNotRetry(t *testing.T) {
+	db := setupItems(t)
+
+	// 嵌套在外层事务中时只执行一次，由外层事务重试
+	inner, outer := 0, 0
+	err := fastRetry.Transaction(db, func(tx *gorm.DB) error {
+		outer++
+		return fastRetry.Transaction(tx, func(tx *gorm.DB) error {
+			inner++
+			if outer < 2 {
+				return errDeadlock
+			}
+			return tx.Create(&item{Name: "nested"}).Error
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outer != 2 || inner != 2 {
+		t.Errorf("外层执行 %d 次, 内层执行 %d 次, 期望各 2 次", outer, inner)
+	}
+}
+
+func TestTransactionStopsOnContextCancel(t *testing.T) {
+	db := setupItems(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	policy := database.RetryPolicy{MaxAttempts: 10, InitialBackoff: time.Hour, MaxBackoff: time.Hour}
+	err := policy.Transaction(db.WithContext(ctx), func(tx *gorm.DB) error {
+		return errDeadlock
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, 期望 context.Canceled", err)
+	}
+}
+
+func TestTransactionRetriesSQLiteBusy(t *testing.T) {
+	// 不等待锁（_busy_timeout=0），另一个连接持有写锁时立即返回 SQLITE_BUSY
+	cfg := &config.Config{
+		Driver: config.DriverSQLite,
+		DSN:    "file:" + filepath.Join(t.TempDir(), "busy.db") + "?_busy_timeout=0&_txlock=immediate",
+	}
+	db, err := database.Open(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&item{}); err != nil {
+		t.Fatal(err)
+	}
+
+	holder := db.Begin()
+	if holder.Error != nil {
+		t.Fatal(holder.Error)
+	}
+	// 不重试时立即失败
+	err = db.Transaction(func(tx *gorm.DB) error { return tx.Create(&item{Name: "once"}).Error })
+	if !database.IsRetryable(err) {
+		t.Fatalf("err = %v, 期望 SQLITE_BUSY", err)
+	}
+
+	released := make(chan struct{})
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		holder.Rollback()
+		close(released)
+	}()
+
+	policy := database.RetryPolicy{MaxAttempts: 20, InitialBackoff: 5 * time.Millisecond, MaxBackoff: 20 * time.Millisecond}
+	// 开始事务（BEGIN IMMEDIATE）时就返回 SQLITE_BUSY，写锁释放后重试成功
+	err = policy.Transaction(db, func(tx *gorm.DB) error {
+		return tx.Create(&item{Name: "busy"}).Error
+	})
+	<-released
+	if err != nil {
+		t.Fatalf("Transaction: %v", err)
+	}
+	if n := countItems(t, db); n != 1 {
+		t.Errorf("记录数 = %d, 期望 1", n)
+	}
+}
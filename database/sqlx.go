@@ -0,0 +1,38 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"gorm.io/gorm"
+)
+
+// 方言名称，与 gorm.Dialector.Name() 的返回值一致
+const (
+	DialectMySQL  = "mysql"
+	DialectSQLite = "sqlite"
+)
+
+// sqlxDriverNames 方言对应的 database/sql 驱动名，sqlx 据此选择占位符风格
+var sqlxDriverNames = map[string]string{
+	DialectMySQL:  "mysql",
+	DialectSQLite: "sqlite3",
+}
+
+// Dialect 返回 GORM 连接使用的方言名称
+func Dialect(db *gorm.DB) string {
+	return db.Dialector.Name()
+}
+
+// NewSqlx 复用 GORM 的连接池创建 sqlx.DB，驱动名与 GORM 实际使用的方言一致
+func NewSqlx(db *gorm.DB) (*sqlx.DB, error) {
+	driverName, ok := sqlxDriverNames[Dialect(db)]
+	if !ok {
+		return nil, fmt.Errorf("sqlx 不支持的方言: %s", Dialect(db))
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("获取 sql.DB 失败: %w", err)
+	}
+	return sqlx.NewDb(sqlDB, driverName), nil
+}
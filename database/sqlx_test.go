@@ -0,0 +1,31 @@
+package database_test
+
+import (
+	"testing"
+
+	"gorm/database"
+	"gorm/internal/testdb"
+)
+
+func TestNewSqlx(t *testing.T) {
+	gdb := testdb.Open(t)
+	if got := database.Dialect(gdb); got != database.DialectSQLite {
+		t.Fatalf("Dialect = %s, 期望 %s", got, database.DialectSQLite)
+	}
+
+	db, err := database.NewSqlx(gdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.DriverName() != "sqlite3" {
+		t.Errorf("DriverName = %s, 期望 sqlite3", db.DriverName())
+	}
+
+	var n int
+	if err := db.Get(&n, db.Rebind("SELECT ? + 1"), 41); err != nil {
+		t.Fatal(err)
+	}
+	if n != 42 {
+		t.Errorf("SELECT ? + 1 = %d, 期望 42", n)
+	}
+}
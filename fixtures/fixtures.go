@@ -0,0 +1,386 @@
+// Package fixtures 从 JSON 文件加载示例的测试数据，通过已注册的模型写入以触发钩子
+package fixtures
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"gorm/database"
+
+	"gorm.io/gorm"
+)
+
+// 声明式测试数据
+// 测试数据按集合（demo、test、load）存放在 data/<集合>/<表名>.json 中，每个文件是一个记录数组，
+// 记录的键为列名，通过已注册的模型写入数据库（因此会触发模型的钩子函数）。
+//
+// 记录之间通过引用关联：
+//   - "_ref": "gorm-intro" 为记录命名
+//   - "post_id": "@posts.gorm-intro" 在写入前替换为 posts 表中名为 gorm-intro 的记录的主键
+// 以 "@@" 开头的字符串表示以 "@" 开头的普通字符串。引用只能指向同一次 Load 中先加载的表。
+//
+// 加载是幂等的，不会删除已有数据：
+//   - 注册了自然键的表按自然键更新已有记录，不存在时插入；用 RegisterKey 注册的其他唯一键（例如 email）
+//     同样用来查找已有记录，自然键和唯一键对应不同的记录时返回 ErrKeyConflict
+//   - 没有自然键的表只在表为空时写入，否则整张表跳过（这类表的记录不应被引用）
+// 需要清空数据时显式调用 Reset。
+
+//go:embed data
+var embedded embed.FS
+
+// 内置的测试数据集合
+const (
+	SetDemo = "demo" // 命令行演示使用
+	SetTest = "test" // 测试使用：最少的数据，每条记录都对应测试中的断言
+	SetLoad = "load" // 大量数据，用于压测
+)
+
+// RefKey 记录名称的键
+const RefKey = "_ref"
+
+// ErrUnknownRef 引用的记录不存在或尚未加载
+var ErrUnknownRef = errors.New("引用的记录不存在")
+
+// ErrKeyConflict 记录的自然键和唯一键对应不同的已有记录，例如用户名属于一个用户、邮箱属于另一个用户
+var ErrKeyConflict = errors.New("记录的唯一键对应不同的已有记录")
+
+var refPattern = regexp.MustCompile(`^@(\w+)\.([\w-]+)$`)
+
+// model 已注册的模型
+type model struct {
+	new        func() any
+	naturalKey []string
+	// uniqueKeys 自然键以外的唯一键
+	uniqueKeys [][]string
+}
+
+var (
+	mu     sync.RWMutex
+	models = make(map[string]model)
+)
+
+// Register 注册表对应的模型，newModel 返回模型的指针，naturalKey 为唯一标识记录的列
+// 各模型所在的包在 init 中注册，fixtures 包本身不依赖具体模型。
+func Register(table string, newModel func() any, naturalKey ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, dup := models[table]; dup {
+		panic(fmt.Sprintf("fixtures: 表 %q 重复注册", table))
+	}
+	models[table] = model{new: newModel, naturalKey: naturalKey}
+}
+
+// RegisterKey 为已注册的表增加一个唯一键，例如 users 表的自然键是 username，email 也有唯一索引
+// 加载时按自然键或任一唯一键找到已有记录都会更新这条记录，不会因为违反唯一索引而失败。
+// 记录中没有给出唯一键的列时，这个唯一键不参与查找。
+func RegisterKey(table string, columns ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	m, ok := models[table]
+	if !ok || len(m.naturalKey) == 0 {
+		panic(fmt.Sprintf("fixtures: 表 %q 没有注册自然键", table))
+	}
+	m.uniqueKeys = append(m.uniqueKeys, columns)
+	models[table] = m
+}
+
+func lookupModel(table string) (model, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	m, ok := models[table]
+	if !ok {
+		return model{}, fmt.Errorf("表 %s 没有注册模型", table)
+	}
+	return m, nil
+}
+
+// Sets 返回内置的测试数据集合名称
+func Sets() []string {
+	entries, _ := fs.ReadDir(embedded, "data")
+	var sets []string
+	for _, e := range entries {
+		if e.IsDir() {
+			sets = append(sets, e.Name())
+		}
+	}
+	sort.Strings(sets)
+	return sets
+}
+
+// Result 一次加载的结果，按表名统计记录数
+type Result struct {
+	Inserted map[string]int // 新插入的记录
+	Updated  map[string]int // 按自然键更新的已有记录
+	Skipped  map[string]int // 表不为空而跳过的记录
+	// refs 表名 -> 记录名 -> 主键
+	refs map[string]map[string]any
+}
+
+// Ref 返回命名记录的主键
+func (r *Result) Ref(table, name string) (any, bool) {
+	id, ok := r.refs[table][name]
+	return id, ok
+}
+
+// Summary 返回一张表的加载统计，用于输出
+func (r *Result) Summary(table string) string {
+	return fmt.Sprintf("插入 %d 条, 更新 %d 条, 跳过 %d 条", r.Inserted[table], r.Updated[table], r.Skipped[table])
+}
+
+// Load 在一个事务中按 tables 的顺序加载内置集合 set 中的数据
+func Load(ctx context.Context, db *gorm.DB, set string, tables ...string) (*Result, error) {
+	return LoadFS(ctx, db, embedded, path.Join("data", set), tables...)
+}
+
+// LoadFS 在一个事务中按 tables 的顺序加载 fsys 的 dir 目录中的数据
+// 事务通过 database.Transaction 执行，写入触发的钩子遇到死锁或数据库忙时整体重试。
+func LoadFS(ctx context.Context, db *gorm.DB, fsys fs.FS, dir string, tables ...string) (*Result, error) {
+	files := make(map[string][]map[string]any, len(tables))
+	for _, table := range tables {
+		records, err := readTable(fsys, dir, table)
+		if err != nil {
+			return nil, err
+		}
+		files[table] = records
+	}
+
+	var result *Result
+	err := database.Transaction(db.WithContext(ctx), func(tx *gorm.DB) error {
+		// 重试时从头加载，不保留上一次执行的统计和引用
+		result = &Result{
+			Inserted: make(map[string]int),
+			Updated:  make(map[string]int),
+			Skipped:  make(map[string]int),
+			refs:     make(map[string]map[string]any),
+		}
+		for _, table := range tables {
+			m, err := lookupModel(table)
+			if err != nil {
+				return err
+			}
+
+			// 没有自然键的表无法判断记录是否已存在，只在表为空时写入
+			if len(m.naturalKey) == 0 {
+				var count int64
+				if err := tx.Model(m.new()).Count(&count).Error; err != nil {
+					return err
+				}
+				if count > 0 {
+					result.Skipped[table] += len(files[table])
+					continue
+				}
+			}
+
+			for i, record := range files[table] {
+				if err := result.upsert(tx, table, m, record); err != nil {
+					return fmt.Errorf("加载 %s 第 %d 条记录失败: %w", table, i+1, err)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// readTable 读取并解析一张表的数据文件
+func readTable(fsys fs.FS, dir, table string) ([]map[string]any, error) {
+	file := path.Join(dir, table+".json")
+	data, err := fs.ReadFile(fsys, file)
+	if err != nil {
+		return nil, fmt.Errorf("读取测试数据 %s 失败: %w", file, err)
+	}
+	var records []map[string]any
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("解析测试数据 %s 失败: %w", file, err)
+	}
+	return records, nil
+}
+
+// upsert 解析引用，把记录写入模型；有自然键时更新已有记录，否则插入
+func (r *Result) upsert(tx *gorm.DB, table string, m model, record map[string]any) error {
+	row := m.new()
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(row); err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(row).Elem()
+	ctx := tx.Statement.Context
+
+	var name string
+	values := make(map[string]any, len(record))
+	for column, value := range record {
+		if column == RefKey {
+			name, _ = value.(string)
+			continue
+		}
+		field := stmt.Schema.LookUpField(column)
+		if field == nil {
+			return fmt.Errorf("表 %s 没有列 %s", table, column)
+		}
+		resolved, err := r.resolve(value)
+		if err != nil {
+			return err
+		}
+		if err := field.Set(ctx, rv, resolved); err != nil {
+			return fmt.Errorf("设置列 %s 失败: %w", column, err)
+		}
+		values[field.DBName] = resolved
+	}
+
+	pk := stmt.Schema.PrioritizedPrimaryField
+	id, err := findExisting(tx, m, values, func(existing any) any {
+		id, _ := pk.ValueOf(ctx, reflect.ValueOf(existing).Elem())
+		return id
+	})
+	if err != nil {
+		return err
+	}
+	if id != nil {
+		// 已有记录：只更新数据文件中给出的列，保留主键
+		if err := pk.Set(ctx, rv, id); err != nil {
+			return err
+		}
+		columns := make([]string, 0, len(values))
+		for column := range values {
+			columns = append(columns, column)
+		}
+		if err := tx.Model(row).Select(columns).Updates(row).Error; err != nil {
+			return err
+		}
+		r.Updated[table]++
+	} else {
+		if err := tx.Create(row).Error; err != nil {
+			return err
+		}
+		r.Inserted[table]++
+	}
+
+	if name != "" {
+		if r.refs[table] == nil {
+			r.refs[table] = make(map[string]any)
+		}
+		if _, dup := r.refs[table][name]; dup {
+			return fmt.Errorf("记录名 %s.%s 重复", table, name)
+		}
+		id, _ := pk.ValueOf(ctx, rv)
+		r.refs[table][name] = id
+	}
+	return nil
+}
+
+// findExisting 按自然键和唯一键查找已有记录，返回其主键；表没有自然键或记录不存在时返回 nil
+// 各个键找到的必须是同一条记录，否则返回 ErrKeyConflict。primaryKey 返回记录的主键。
+func findExisting(tx *gorm.DB, m model, values map[string]any, primaryKey func(any) any) (any, error) {
+	if len(m.naturalKey) == 0 {
+		return nil, nil
+	}
+	var found any
+	var foundKey []string
+	for i, key := range append([][]string{m.naturalKey}, m.uniqueKeys...) {
+		where := make(map[string]any, len(key))
+		for _, column := range key {
+			v, ok := values[column]
+			if !ok {
+				break
+			}
+			where[column] = v
+		}
+		if len(where) < len(key) {
+			if i == 0 {
+				return nil, fmt.Errorf("记录缺少自然键列 %s", strings.Join(key, ", "))
+			}
+			continue
+		}
+
+		// 使用 Find 而不是 Take，记录不存在是正常情况，不应输出 record not found 日志
+		existing := m.new()
+		result := tx.Where(where).Limit(1).Find(existing)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		id := primaryKey(existing)
+		if found != nil && !reflect.DeepEqual(found, id) {
+			return nil, fmt.Errorf("%w: %s 对应记录 %v，%s 对应记录 %v",
+				ErrKeyConflict, strings.Join(foundKey, ", "), found, strings.Join(key, ", "), id)
+		}
+		found, foundKey = id, key
+	}
+	return found, nil
+}
+
+// Reset 按 tables 的逆序删除表中的全部数据，用于显式的破坏性重置
+// tables 的顺序与 Load 相同（被引用的表在前），逆序删除可以满足外键约束。
+func Reset(ctx context.Context, db *gorm.DB, tables ...string) error {
+	return database.Transaction(db.WithContext(ctx), func(tx *gorm.DB) error {
+		for i := len(tables) - 1; i >= 0; i-- {
+			m, err := lookupModel(tables[i])
+			if err != nil {
+				return err
+			}
+			if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(m.new()).Error; err != nil {
+				return fmt.Errorf("清空 %s 表失败: %w", tables[i], err)
+			}
+		}
+		return nil
+	})
+}
+
+// resolve 把 "@表名.记录名" 替换为记录的主键
+func (r *Result) resolve(value any) (any, error) {
+	s, ok := value.(string)
+	if !ok || !strings.HasPrefix(s, "@") {
+		return value, nil
+	}
+	if strings.HasPrefix(s, "@@") {
+		return s[1:], nil
+	}
+	m := refPattern.FindStringSubmatch(s)
+	if m == nil {
+		return nil, fmt.Errorf("无法解析的引用 %q（格式: @表名.记录名）", s)
+	}
+	id, ok := r.Ref(m[1], m[2])
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownRef, s)
+	}
+	return id, nil
+}
+
+// Options 示例加载测试数据的选项
+type Options struct {
+	// Set 加载的测试数据集合
+	Set string
+	// Reset 加载前清空相关表中的全部数据
+	Reset bool
+}
+
+type ctxKey struct{}
+
+// NewContext 返回携带加载选项的 context
+func NewContext(ctx context.Context, opts Options) context.Context {
+	return context.WithValue(ctx, ctxKey{}, opts)
+}
+
+// FromContext 取出 context 中的加载选项，没有指定集合时使用 demo
+func FromContext(ctx context.Context) Options {
+	opts, _ := ctx.Value(ctxKey{}).(Options)
+	if opts.Set == "" {
+		opts.Set = SetDemo
+	}
+	return opts
+}
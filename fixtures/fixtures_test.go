@@ -0,0 +1,308 @@
+package fixtures_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"gorm/fixtures"
+	_ "gorm/gormAdvanced"
+	"gorm/internal/testdb"
+	"gorm/migrate"
+	_ "gorm/sqlxOne"
+	_ "gorm/sqlxTwo"
+
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/gorm"
+)
+
+type author struct {
+	ID    uint
+	Name  string
+	Books int
+}
+
+type novel struct {
+	ID       uint
+	Title    string
+	AuthorID uint
+	Price    float64
+}
+
+// busyNovels 大于 0 时 BeforeCreate 模拟数据库忙，用于验证加载事务会重试
+var busyNovels int
+
+// BeforeCreate 验证加载数据时会触发模型钩子
+func (n *novel) BeforeCreate(tx *gorm.DB) error {
+	if busyNovels > 0 {
+		busyNovels--
+		return sqlite3.Error{Code: sqlite3.ErrBusy}
+	}
+	return tx.Model(&author{}).Where("id = ?", n.AuthorID).UpdateColumn("books", gorm.Expr("books + 1")).Error
+}
+
+// review 没有自然键的模型
+type review struct {
+	ID      uint
+	NovelID uint
+	Content string
+}
+
+func init() {
+	fixtures.Register("authors", func() any { return &author{} }, "name")
+	fixtures.Register("novels", func() any { return &novel{} }, "title", "author_id")
+	fixtures.Register("reviews", func() any { return &review{} })
+}
+
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := testdb.Open(t)
+	if err := db.AutoMigrate(&author{}, &novel{}, &review{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestLoadFSResolvesRefs(t *testing.T) {
+	db := openDB(t)
+	fsys := fstest.MapFS{
+		"set/authors.json": {Data: []byte(`[
+			{"_ref": "jin", "name": "金庸"},
+			{"_ref": "liu", "name": "@@刘慈欣"}
+		]`)},
+		"set/novels.json": {Data: []byte(`[
+			{"title": "天龙八部", "author_id": "@authors.jin", "price": 78.5},
+			{"title": "射雕英雄传", "author_id": "@authors.jin", "price": 68},
+			{"title": "三体", "author_id": "@authors.liu", "price": 45}
+		]`)},
+	}
+
+	result, err := fixtures.LoadFS(context.Background(), db, fsys, "set", "authors", "novels")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Inserted["authors"] != 2 || result.Inserted["novels"] != 3 {
+		t.Errorf("Inserted = %v", result.Inserted)
+	}
+
+	jinID, ok := result.Ref("authors", "jin")
+	if !ok {
+		t.Fatal("缺少记录 authors.jin")
+	}
+	var jin author
+	if err := db.First(&jin, jinID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if jin.Name != "金庸" || jin.Books != 2 {
+		t.Errorf("金庸 = %+v, 期望钩子把 Books 更新为 2", jin)
+	}
+
+	var liu author
+	db.Where("id = ?", must(result.Ref("authors", "liu"))).First(&liu)
+	if liu.Name != "@刘慈欣" {
+		t.Errorf("@@ 转义后的名字 = %q", liu.Name)
+	}
+
+	var santi novel
+	db.Where("title = ?", "三体").First(&santi)
+	if santi.AuthorID != liu.ID || santi.Price != 45 {
+		t.Errorf("三体 = %+v", santi)
+	}
+}
+
+func must(v any, _ bool) any { return v }
+
+func TestLoadFSIsIdempotent(t *testing.T) {
+	db := openDB(t)
+	fsys := fstest.MapFS{
+		"set/authors.json": {Data: []byte(`[{"_ref": "jin", "name": "金庸", "books": 0}]`)},
+		"set/novels.json": {Data: []byte(`[
+			{"_ref": "tlbb", "title": "天龙八部", "author_id": "@authors.jin", "price": 78.5},
+			{"title": "射雕英雄传", "author_id": "@authors.jin", "price": 68}
+		]`)},
+		"set/reviews.json": {Data: []byte(`[{"novel_id": "@novels.tlbb", "content": "好看"}]`)},
+	}
+	ctx := context.Background()
+	tables := []string{"authors", "novels", "reviews"}
+
+	if _, err := fixtures.LoadFS(ctx, db, fsys, "set", tables...); err != nil {
+		t.Fatal(err)
+	}
+	// 修改已有数据，重新加载后按自然键恢复
+	db.Model(&novel{}).Where("title = ?", "天龙八部").Update("price", 1)
+
+	result, err := fixtures.LoadFS(ctx, db, fsys, "set", tables...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Inserted["authors"] != 0 || result.Updated["authors"] != 1 ||
+		result.Inserted["novels"] != 0 || result.Updated["novels"] != 2 ||
+		result.Skipped["reviews"] != 1 {
+		t.Errorf("第二次加载: inserted=%v updated=%v skipped=%v", result.Inserted, result.Updated, result.Skipped)
+	}
+
+	counts := map[string]int64{}
+	for table, model := range map[string]any{"authors": &author{}, "novels": &novel{}, "reviews": &review{}} {
+		var n int64
+		db.Model(model).Count(&n)
+		counts[table] = n
+	}
+	if counts["authors"] != 1 || counts["novels"] != 2 || counts["reviews"] != 1 {
+		t.Errorf("重复加载后的记录数 = %v", counts)
+	}
+
+	var tlbb novel
+	db.Where("title = ?", "天龙八部").First(&tlbb)
+	if tlbb.Price != 78.5 {
+		t.Errorf("天龙八部 价格 = %v, 期望恢复为 78.5", tlbb.Price)
+	}
+	// 更新已有记录不会再次触发 BeforeCreate 钩子，数据文件中的 books 把计数恢复为 0
+	var jin author
+	db.Where("name = ?", "金庸").First(&jin)
+	if jin.Books != 0 {
+		t.Errorf("金庸 Books = %d, 期望 0", jin.Books)
+	}
+}
+
+func TestLoadFSRetriesBusyHooks(t *testing.T) {
+	db := openDB(t)
+	fsys := fstest.MapFS{
+		"set/authors.json": {Data: []byte(`[{"_ref": "jin", "name": "金庸"}]`)},
+		"set/novels.json": {Data: []byte(`[
+			{"title": "天龙八部", "author_id": "@authors.jin"},
+			{"title": "射雕英雄传", "author_id": "@authors.jin"}
+		]`)},
+	}
+	busyNovels = 1
+	t.Cleanup(func() { busyNovels = 0 })
+
+	result, err := fixtures.LoadFS(context.Background(), db, fsys, "set", "authors", "novels")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 重试从头执行，统计只反映成功的那一次
+	if result.Inserted["authors"] != 1 || result.Inserted["novels"] != 2 {
+		t.Errorf("Inserted = %v", result.Inserted)
+	}
+	var jin author
+	db.Where("name = ?", "金庸").First(&jin)
+	if jin.Books != 2 {
+		t.Errorf("金庸 Books = %d, 期望 2", jin.Books)
+	}
+}
+
+func TestLoadFSRequiresNaturalKey(t *testing.T) {
+	db := openDB(t)
+	fsys := fstest.MapFS{
+		"set/authors.json": {Data: []byte(`[{"books": 1}]`)},
+	}
+	if _, err := fixtures.LoadFS(context.Background(), db, fsys, "set", "authors"); err == nil {
+		t.Fatal("记录缺少自然键时期望加载失败")
+	}
+}
+
+func TestReset(t *testing.T) {
+	db := openDB(t)
+	fsys := fstest.MapFS{
+		"set/authors.json": {Data: []byte(`[{"_ref": "jin", "name": "金庸"}]`)},
+		"set/novels.json":  {Data: []byte(`[{"title": "天龙八部", "author_id": "@authors.jin"}]`)},
+	}
+	ctx := context.Background()
+	if _, err := fixtures.LoadFS(ctx, db, fsys, "set", "authors", "novels"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fixtures.Reset(ctx, db, "authors", "novels"); err != nil {
+		t.Fatal(err)
+	}
+	var authors, novels int64
+	db.Model(&author{}).Count(&authors)
+	db.Model(&novel{}).Count(&novels)
+	if authors != 0 || novels != 0 {
+		t.Errorf("Reset 后 authors=%d novels=%d", authors, novels)
+	}
+}
+
+func TestLoadFSErrorsRollBack(t *testing.T) {
+	tests := []struct {
+		name    string
+		novels  string
+		wantErr error
+	}{
+		{"未知引用", `[{"title": "x", "author_id": "@authors.nobody"}]`, fixtures.ErrUnknownRef},
+		{"未知列", `[{"title": "x", "isbn": "123"}]`, nil},
+		{"引用格式错误", `[{"title": "x", "author_id": "@authors"}]`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openDB(t)
+			fsys := fstest.MapFS{
+				"set/authors.json": {Data: []byte(`[{"_ref": "jin", "name": "金庸"}]`)},
+				"set/novels.json":  {Data: []byte(tt.novels)},
+			}
+			_, err := fixtures.LoadFS(context.Background(), db, fsys, "set", "authors", "novels")
+			if err == nil {
+				t.Fatal("期望加载失败")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, 期望 %v", err, tt.wantErr)
+			}
+
+			// 整个加载在一个事务中，失败后不应留下任何记录
+			var count int64
+			db.Model(&author{}).Count(&count)
+			if count != 0 {
+				t.Errorf("失败后 authors 表有 %d 条记录", count)
+			}
+		})
+	}
+}
+
+func TestBuiltinSets(t *testing.T) {
+	want := []string{fixtures.SetDemo, fixtures.SetLoad, fixtures.SetTest}
+	got := fixtures.Sets()
+	if len(got) != len(want) {
+		t.Fatalf("Sets() = %v, 期望 %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Sets() = %v, 期望 %v", got, want)
+		}
+	}
+}
+
+func TestFromContextDefaultsToDemo(t *testing.T) {
+	if got := fixtures.FromContext(context.Background()).Set; got != fixtures.SetDemo {
+		t.Errorf("默认集合 = %s, 期望 %s", got, fixtures.SetDemo)
+	}
+	ctx := fixtures.NewContext(context.Background(), fixtures.Options{Set: fixtures.SetLoad})
+	if got := fixtures.FromContext(ctx).Set; got != fixtures.SetLoad {
+		t.Errorf("集合 = %s, 期望 %s", got, fixtures.SetLoad)
+	}
+}
+
+// TestBuiltinSetsLoad 内置集合都能通过已注册的模型完整加载
+func TestBuiltinSetsLoad(t *testing.T) {
+	tables := []string{"employees", "books", "users", "posts", "comments"}
+	for _, set := range fixtures.Sets() {
+		t.Run(set, func(t *testing.T) {
+			db := testdb.Open(t)
+			if _, err := migrate.Up(context.Background(), db); err != nil {
+				t.Fatal(err)
+			}
+			result, err := fixtures.Load(context.Background(), db, set, tables...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, table := range tables {
+				if result.Inserted[table] == 0 {
+					t.Errorf("%s 没有加载任何记录", table)
+				}
+			}
+			if _, ok := result.Ref("posts", "gorm-intro"); !ok {
+				t.Error("缺少示例依赖的文章 gorm-intro")
+			}
+		})
+	}
+}
@@ -1,10 +1,19 @@
 package advancedone
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"time"
 
+	"gorm/database"
+	"gorm/fixtures"
+	"gorm/migrate"
+	"gorm/presenter"
+	"gorm/scenario"
+
 	"gorm.io/gorm"
 )
 
@@ -38,51 +47,51 @@ import (
 
 // User 用户模型
 type User struct {
-	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	Username  string    `gorm:"type:varchar(50);uniqueIndex;not null" json:"username"`
-	Email     string    `gorm:"type:varchar(100);uniqueIndex;not null" json:"email"`
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id" label:"ID"`
+	Username  string    `gorm:"type:varchar(50);uniqueIndex;not null" json:"username" label:"用户名"`
+	Email     string    `gorm:"type:varchar(100);uniqueIndex;not null" json:"email" label:"邮箱"`
 	Password  string    `gorm:"type:varchar(255);not null" json:"-"` // json:"-" 表示不序列化密码
-	Nickname  string    `gorm:"type:varchar(50)" json:"nickname"`
-	PostCount int       `gorm:"default:0" json:"post_count"` // 题目3：文章数量统计字段
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	Nickname  string    `gorm:"type:varchar(50)" json:"nickname" label:"昵称"`
+	PostCount int       `gorm:"default:0" json:"post_count" label:"文章数量"` // 题目3：文章数量统计字段
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at" label:"创建时间"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at" label:"更新时间"`
 
 	// 一对多关系：一个用户可以发布多篇文章
-	Posts []Post `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"posts,omitempty"`
+	Posts []Post `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"posts,omitempty" label:"文章"`
 }
 
 // Post 文章模型
 type Post struct {
-	ID            uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	Title         string    `gorm:"type:varchar(200);not null;index" json:"title"`
-	Content       string    `gorm:"type:text;not null" json:"content"`
-	UserID        uint      `gorm:"not null;index" json:"user_id"` // 外键：关联用户
-	ViewCount     int       `gorm:"default:0" json:"view_count"`
-	CommentStatus string    `gorm:"type:varchar(20);default:'有评论'" json:"comment_status"` // 题目3：评论状态
-	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt     time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID            uint      `gorm:"primaryKey;autoIncrement" json:"id" label:"文章ID"`
+	Title         string    `gorm:"type:varchar(200);not null;index" json:"title" label:"标题"`
+	Content       string    `gorm:"type:text;not null" json:"content" label:"内容"`
+	UserID        uint      `gorm:"not null;index" json:"user_id" label:"作者ID"` // 外键：关联用户
+	ViewCount     int       `gorm:"default:0" json:"view_count" label:"浏览量"`
+	CommentStatus string    `gorm:"type:varchar(20);default:'有评论'" json:"comment_status" label:"评论状态"` // 题目3：评论状态
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at" label:"创建时间"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime" json:"updated_at" label:"更新时间"`
 
 	// 多对一关系：多篇文章属于一个用户
-	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	User User `gorm:"foreignKey:UserID" json:"user,omitempty" label:"作者"`
 
 	// 一对多关系：一篇文章可以有多个评论
-	Comments []Comment `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE" json:"comments,omitempty"`
+	Comments []Comment `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE" json:"comments,omitempty" label:"评论"`
 }
 
 // Comment 评论模型
 type Comment struct {
-	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	Content   string    `gorm:"type:text;not null" json:"content"`
-	PostID    uint      `gorm:"not null;index" json:"post_id"` // 外键：关联文章
-	UserID    uint      `gorm:"not null;index" json:"user_id"` // 外键：关联用户（评论者）
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id" label:"评论ID"`
+	Content   string    `gorm:"type:text;not null" json:"content" label:"内容"`
+	PostID    uint      `gorm:"not null;index" json:"post_id" label:"文章ID"`  // 外键：关联文章
+	UserID    uint      `gorm:"not null;index" json:"user_id" label:"评论者ID"` // 外键：关联用户（评论者）
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at" label:"创建时间"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at" label:"更新时间"`
 
 	// 多对一关系：多个评论属于一篇文章
-	Post Post `gorm:"foreignKey:PostID" json:"post,omitempty"`
+	Post Post `gorm:"foreignKey:PostID" json:"post,omitempty" label:"文章"`
 
 	// 多对一关系：多个评论属于一个用户
-	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	User User `gorm:"foreignKey:UserID" json:"user,omitempty" label:"评论者"`
 }
 
 // ============================================
@@ -123,6 +132,28 @@ func (c *Comment) AfterDelete(tx *gorm.DB) error {
 	return nil
 }
 
+// CreatePost 创建文章，BeforeCreate 钩子在同一个事务中更新作者的文章数量
+// 事务因死锁或锁等待超时失败时由 database.Transaction 整体重试。
+func CreatePost(db *gorm.DB, post *Post) error {
+	return database.Transaction(db, func(tx *gorm.DB) error {
+		// 每次重试从调用方传入的文章开始，失败的尝试不会留下主键等状态
+		attempt := *post
+		if err := tx.Create(&attempt).Error; err != nil {
+			return err
+		}
+		*post = attempt
+		return nil
+	})
+}
+
+// DeleteComment 删除评论，AfterDelete 钩子在同一个事务中更新文章的评论状态
+// 事务因死锁或锁等待超时失败时由 database.Transaction 整体重试。
+func DeleteComment(db *gorm.DB, comment *Comment) error {
+	return database.Transaction(db, func(tx *gorm.DB) error {
+		return tx.Delete(comment).Error
+	})
+}
+
 // ============================================
 // TableName 方法：自定义表名（可选）
 // ============================================
@@ -140,201 +171,183 @@ func (Comment) TableName() string {
 }
 
 // ============================================
-// Run 执行博客系统模型创建
+// Scenario 博客系统示例
 // ============================================
 
-func Run(db *gorm.DB) {
-	fmt.Println("\n========== 开始执行 GORM 高级模型定义示例 ==========")
-
-	// 1. 自动迁移：创建表
-	createTables(db)
-
-	// 2. 插入测试数据
-	insertTestData(db)
-
-	// 3. 演示查询功能
-	demonstrateQueries(db)
-
-	fmt.Println("========== GORM 高级模型定义示例执行完毕 ==========\n")
+func init() {
+	// 用户按用户名或邮箱、文章按标题和作者识别，重复加载时更新已有记录；评论只在表为空时写入
+	fixtures.Register("users", func() any { return &User{} }, "username")
+	fixtures.RegisterKey("users", "email")
+	fixtures.Register("posts", func() any { return &Post{} }, "title", "user_id")
+	fixtures.Register("comments", func() any { return &Comment{} })
+	scenario.Register(&Scenario{Username: "alice"})
 }
 
-// createTables 创建数据库表
-func createTables(db *gorm.DB) {
-	fmt.Println("\n--- 创建数据库表 ---")
-
-	// 自动迁移（按顺序迁移，确保外键关系正确）
-	err := db.AutoMigrate(&User{}, &Post{}, &Comment{})
-	if err != nil {
-		log.Printf("自动迁移失败: %v\n", err)
-		return
-	}
-
-	fmt.Println("数据库表创建成功：users, posts, comments")
+// Scenario 博客系统模型、关联查询和钩子函数示例
+type Scenario struct {
+	// Username 关联查询演示中查询的用户
+	Username string
 }
 
-// insertTestData 插入测试数据
-func insertTestData(db *gorm.DB) {
-	fmt.Println("\n--- 插入测试数据 ---")
-
-	// 清空旧数据（按顺序删除，先删除依赖表）
-	db.Exec("DELETE FROM comments")
-	db.Exec("DELETE FROM posts")
-	db.Exec("DELETE FROM users")
-
-	// 创建用户
-	user1 := User{
-		Username: "alice",
-		Email:    "alice@example.com",
-		Password: "hashed_password_123",
-		Nickname: "Alice",
-	}
+func (*Scenario) Name() string { return "blog" }
+func (*Scenario) Description() string {
+	return "进阶 GORM：博客系统模型、关联查询和钩子"
+}
 
-	user2 := User{
-		Username: "bob",
-		Email:    "bob@example.com",
-		Password: "hashed_password_456",
-		Nickname: "Bob",
-	}
+// BindFlags 注册查询用户名参数
+func (s *Scenario) BindFlags(fs *flag.FlagSet) {
+	fs.StringVar(&s.Username, "username", s.Username, "blog: 查询文章及评论的用户名")
+}
 
-	if err := db.Create(&user1).Error; err != nil {
-		log.Printf("创建用户1失败: %v\n", err)
-		return
-	}
+// Setup 创建表并插入测试数据
+func (*Scenario) Setup(ctx context.Context, db *gorm.DB) error {
+	db = db.WithContext(ctx)
 
-	if err := db.Create(&user2).Error; err != nil {
-		log.Printf("创建用户2失败: %v\n", err)
-		return
+	// 1. 数据库迁移：创建表
+	if err := createTables(db); err != nil {
+		return err
 	}
 
-	fmt.Printf("创建用户成功: %s (ID: %d), %s (ID: %d)\n", user1.Username, user1.ID, user2.Username, user2.ID)
+	// 2. 插入测试数据
+	return insertTestData(db)
+}
 
-	// 创建文章
-	post1 := Post{
-		Title:   "GORM 入门教程",
-		Content: "这是一篇关于 GORM 的入门教程，介绍了如何使用 GORM 进行数据库操作...",
-		UserID:  user1.ID,
-	}
+func (*Scenario) Teardown(context.Context, *gorm.DB) error { return nil }
 
-	post2 := Post{
-		Title:   "Go 语言最佳实践",
-		Content: "本文分享了一些 Go 语言开发的最佳实践，包括代码组织、错误处理等...",
-		UserID:  user1.ID,
-	}
+// Run 演示关联查询和钩子函数
+func (s *Scenario) Run(ctx context.Context, db *gorm.DB) error {
+	fmt.Println("\n========== 开始执行 GORM 高级模型定义示例 ==========")
 
-	post3 := Post{
-		Title:   "微服务架构设计",
-		Content: "探讨微服务架构的设计原则和实践经验...",
-		UserID:  user2.ID,
+	// 3. 演示查询功能
+	if err := demonstrateQueries(db.WithContext(ctx), s.Username); err != nil {
+		return err
 	}
 
-	if err := db.Create(&post1).Error; err != nil {
-		log.Printf("创建文章1失败: %v\n", err)
-		return
-	}
+	fmt.Println("========== GORM 高级模型定义示例执行完毕 ==========")
+	fmt.Println()
+	return nil
+}
 
-	if err := db.Create(&post2).Error; err != nil {
-		log.Printf("创建文章2失败: %v\n", err)
-		return
-	}
+// createTables 执行数据库迁移创建表
+func createTables(db *gorm.DB) error {
+	fmt.Println("\n--- 创建数据库表 ---")
 
-	if err := db.Create(&post3).Error; err != nil {
-		log.Printf("创建文章3失败: %v\n", err)
-		return
+	// 迁移脚本按依赖顺序创建表，确保外键关系正确
+	if _, err := migrate.Up(db.Statement.Context, db); err != nil {
+		return err
 	}
 
-	fmt.Printf("创建文章成功: %d 篇\n", 3)
+	fmt.Println("数据库表创建成功：users, posts, comments")
+	return nil
+}
 
-	// 创建评论
-	comment1 := Comment{
-		Content: "这篇文章写得很好，学到了很多！",
-		PostID:  post1.ID,
-		UserID:  user2.ID, // Bob 评论 Alice 的文章
-	}
+// insertTestData 加载测试数据，已有数据按自然键更新而不会被删除；指定 -reset 时先清空表
+func insertTestData(db *gorm.DB) error {
+	fmt.Println("\n--- 插入测试数据 ---")
 
-	comment2 := Comment{
-		Content: "感谢分享，期待更多内容！",
-		PostID:  post1.ID,
-		UserID:  user2.ID,
-	}
+	ctx := db.Statement.Context
+	opts := fixtures.FromContext(ctx)
+	tables := []string{"users", "posts", "comments"}
 
-	comment3 := Comment{
-		Content: "赞同作者的观点！",
-		PostID:  post2.ID,
-		UserID:  user2.ID,
+	if opts.Reset {
+		if err := fixtures.Reset(ctx, db, tables...); err != nil {
+			return err
+		}
+		fmt.Println("已清空 users, posts, comments 表")
 	}
 
-	comment4 := Comment{
-		Content: "非常实用的架构设计思路！",
-		PostID:  post3.ID,
-		UserID:  user1.ID, // Alice 评论 Bob 的文章
+	// 加载测试数据：用户、文章和评论（新建文章时会触发 BeforeCreate 钩子更新用户文章数量）
+	result, err := fixtures.Load(ctx, db, opts.Set, tables...)
+	if err != nil {
+		return err
 	}
 
-	comments := []Comment{comment1, comment2, comment3, comment4}
-	if err := db.Create(&comments).Error; err != nil {
-		log.Printf("创建评论失败: %v\n", err)
-		return
+	fmt.Printf("加载测试数据集 %s\n", opts.Set)
+	for _, table := range tables {
+		fmt.Printf("  %s: %s\n", table, result.Summary(table))
 	}
-
-	fmt.Printf("创建评论成功: %d 条\n", len(comments))
+	return nil
 }
 
 // demonstrateQueries 演示查询功能
-func demonstrateQueries(db *gorm.DB) {
+func demonstrateQueries(db *gorm.DB, username string) error {
 	fmt.Println("\n========================================")
 	fmt.Println("题目2：关联查询")
 	fmt.Println("========================================")
 
+	p := presenter.FromContext(db.Statement.Context)
+
 	// 题目2-1：查询某个用户发布的所有文章及其对应的评论信息
-	queryUserPostsWithComments(db, "alice")
+	fmt.Printf("\n>>> 【题目2-1】查询用户 '%s' 发布的所有文章及其评论信息\n", username)
+	user, err := QueryUserPostsWithComments(db, username)
+	if err != nil {
+		return err
+	}
+	if err := p.Print("用户及其文章", user); err != nil {
+		return err
+	}
 
 	// 题目2-2：查询评论数量最多的文章信息
-	queryMostCommentedPost(db)
+	fmt.Println("\n>>> 【题目2-2】查询评论数量最多的文章信息")
+	mostCommented, err := QueryMostCommentedPost(db)
+	if err != nil {
+		return err
+	}
+	if err := p.Print("评论数量最多的文章", mostCommented); err != nil {
+		return err
+	}
 
 	fmt.Println("\n========================================")
 	fmt.Println("题目3：钩子函数演示")
 	fmt.Println("========================================")
 
-	// 题目3-1：演示 Post 创建钩子（自动更新用户文章数量）
-	demonstratePostCreateHook(db)
+	// 钩子演示会创建文章和评论，在事务中执行并在结束后回滚，重复运行不会留下演示数据
+	err = database.Transaction(db, func(tx *gorm.DB) error {
+		// 题目3-1：演示 Post 创建钩子（自动更新用户文章数量）
+		if err := demonstratePostCreateHook(tx); err != nil {
+			return err
+		}
 
-	// 题目3-2：演示 Comment 删除钩子（更新文章评论状态）
-	demonstrateCommentDeleteHook(db)
+		// 题目3-2：演示 Comment 删除钩子（更新文章评论状态）
+		if err := demonstrateCommentDeleteHook(tx); err != nil {
+			return err
+		}
+		return errRollbackDemo
+	})
+	if errors.Is(err, errRollbackDemo) {
+		fmt.Println("\n钩子演示数据已回滚")
+		return nil
+	}
+	return err
 }
 
+// errRollbackDemo 用于在钩子演示结束后回滚事务
+var errRollbackDemo = errors.New("回滚钩子演示数据")
+
 // ============================================
 // 题目2：关联查询实现
 // ============================================
 
-// queryUserPostsWithComments 查询某个用户发布的所有文章及其对应的评论信息
-func queryUserPostsWithComments(db *gorm.DB, username string) {
-	fmt.Printf("\n>>> 【题目2-1】查询用户 '%s' 发布的所有文章及其评论信息\n", username)
-
+// QueryUserPostsWithComments 查询某个用户发布的所有文章及其对应的评论信息
+// 返回的 User 预加载了 Posts、Posts.Comments 和评论者 Comments.User。
+func QueryUserPostsWithComments(db *gorm.DB, username string) (*User, error) {
 	var user User
 	// 预加载文章，并预加载文章的评论和评论的用户信息
 	if err := db.Preload("Posts.Comments.User").Where("username = ?", username).First(&user).Error; err != nil {
-		log.Printf("查询失败: %v\n", err)
-		return
-	}
-
-	fmt.Printf("用户: %s (昵称: %s)\n", user.Username, user.Nickname)
-	fmt.Printf("文章数量: %d (PostCount字段: %d)\n", len(user.Posts), user.PostCount)
-	fmt.Println("---")
-
-	for i, post := range user.Posts {
-		fmt.Printf("[文章 %d] %s\n", i+1, post.Title)
-		fmt.Printf("  评论状态: %s\n", post.CommentStatus)
-		fmt.Printf("  评论数量: %d\n", len(post.Comments))
-		for j, comment := range post.Comments {
-			fmt.Printf("    [评论 %d] %s: %s\n", j+1, comment.User.Username, comment.Content)
-		}
-		fmt.Println()
+		return nil, fmt.Errorf("查询用户 %s 的文章失败: %w", username, err)
 	}
+	return &user, nil
 }
 
-// queryMostCommentedPost 查询评论数量最多的文章信息
-func queryMostCommentedPost(db *gorm.DB) {
-	fmt.Println("\n>>> 【题目2-2】查询评论数量最多的文章信息")
+// MostCommentedPost 评论数量最多的文章及其评论数量
+type MostCommentedPost struct {
+	Post
+	CommentCount int64 `json:"comment_count" label:"评论数量"`
+}
 
+// QueryMostCommentedPost 查询评论数量最多的文章信息
+// 返回的 Post 预加载了作者 User 和评论 Comments.User；没有任何评论时返回 gorm.ErrRecordNotFound。
+func QueryMostCommentedPost(db *gorm.DB) (*MostCommentedPost, error) {
 	// 方法1：使用子查询和 Group By
 	type PostCommentCount struct {
 		PostID       uint
@@ -350,27 +363,18 @@ func queryMostCommentedPost(db *gorm.DB) {
 		Scan(&result).Error
 
 	if err != nil {
-		log.Printf("查询失败: %v\n", err)
-		return
+		return nil, fmt.Errorf("查询评论数量失败: %w", err)
+	}
+	if result.PostID == 0 {
+		return nil, fmt.Errorf("查询评论数量最多的文章失败: %w", gorm.ErrRecordNotFound)
 	}
 
 	// 根据 PostID 查询文章详细信息
 	var post Post
 	if err := db.Preload("User").Preload("Comments.User").First(&post, result.PostID).Error; err != nil {
-		log.Printf("查询文章详情失败: %v\n", err)
-		return
-	}
-
-	fmt.Printf("评论数量最多的文章:\n")
-	fmt.Printf("  文章ID: %d\n", post.ID)
-	fmt.Printf("  标题: %s\n", post.Title)
-	fmt.Printf("  作者: %s\n", post.User.Username)
-	fmt.Printf("  评论数量: %d\n", len(post.Comments))
-	fmt.Printf("  评论状态: %s\n", post.CommentStatus)
-	fmt.Println("  所有评论:")
-	for i, comment := range post.Comments {
-		fmt.Printf("    [%d] %s: %s\n", i+1, comment.User.Username, comment.Content)
+		return nil, fmt.Errorf("查询文章详情失败: %w", err)
 	}
+	return &MostCommentedPost{Post: post, CommentCount: result.CommentCount}, nil
 }
 
 // ============================================
@@ -378,12 +382,14 @@ func queryMostCommentedPost(db *gorm.DB) {
 // ============================================
 
 // demonstratePostCreateHook 演示 Post 创建钩子
-func demonstratePostCreateHook(db *gorm.DB) {
+func demonstratePostCreateHook(db *gorm.DB) error {
 	fmt.Println("\n>>> 【题目3-1】演示 Post 创建钩子：自动更新用户文章数量")
 
 	// 查询 alice 用户当前的文章数量
 	var user User
-	db.Where("username = ?", "alice").First(&user)
+	if err := db.Where("username = ?", "alice").First(&user).Error; err != nil {
+		return fmt.Errorf("查询用户 alice 失败: %w", err)
+	}
 	fmt.Printf("创建前: alice 的文章数量 = %d\n", user.PostCount)
 
 	// 创建新文章（会触发 BeforeCreate 钩子）
@@ -393,32 +399,38 @@ func demonstratePostCreateHook(db *gorm.DB) {
 		UserID:  user.ID,
 	}
 
-	if err := db.Create(&newPost).Error; err != nil {
-		log.Printf("创建文章失败: %v\n", err)
-		return
+	if err := CreatePost(db, &newPost); err != nil {
+		return fmt.Errorf("创建文章失败: %w", err)
 	}
 
 	// 重新查询用户，查看文章数量是否自动更新
-	db.Where("username = ?", "alice").First(&user)
+	if err := db.Where("username = ?", "alice").First(&user).Error; err != nil {
+		return fmt.Errorf("查询用户 alice 失败: %w", err)
+	}
 	fmt.Printf("创建后: alice 的文章数量 = %d (自动 +1)\n", user.PostCount)
 	fmt.Printf("新文章ID: %d, 标题: %s\n", newPost.ID, newPost.Title)
+	return nil
 }
 
 // demonstrateCommentDeleteHook 演示 Comment 删除钩子
-func demonstrateCommentDeleteHook(db *gorm.DB) {
+func demonstrateCommentDeleteHook(db *gorm.DB) error {
 	fmt.Println("\n>>> 【题目3-2】演示 Comment 删除钩子：更新文章评论状态")
 
 	// 查找一篇只有一条评论的文章
 	// 先创建一篇新文章
 	var user User
-	db.Where("username = ?", "bob").First(&user)
+	if err := db.Where("username = ?", "bob").First(&user).Error; err != nil {
+		return fmt.Errorf("查询用户 bob 失败: %w", err)
+	}
 
 	testPost := Post{
 		Title:   "测试评论删除钩子的文章",
 		Content: "这篇文章用来测试评论删除钩子",
 		UserID:  user.ID,
 	}
-	db.Create(&testPost)
+	if err := CreatePost(db, &testPost); err != nil {
+		return fmt.Errorf("创建文章失败: %w", err)
+	}
 
 	// 创建一条评论
 	testComment := Comment{
@@ -426,27 +438,37 @@ func demonstrateCommentDeleteHook(db *gorm.DB) {
 		PostID:  testPost.ID,
 		UserID:  user.ID,
 	}
-	db.Create(&testComment)
+	if err := db.Create(&testComment).Error; err != nil {
+		return fmt.Errorf("创建评论失败: %w", err)
+	}
 
 	// 更新文章的评论状态为"有评论"
-	db.Model(&testPost).Update("comment_status", "有评论")
+	if err := db.Model(&testPost).Update("comment_status", "有评论").Error; err != nil {
+		return fmt.Errorf("更新文章评论状态失败: %w", err)
+	}
 
 	fmt.Printf("删除前: 文章 '%s' 的评论状态 = '%s'\n", testPost.Title, testPost.CommentStatus)
 
 	// 查询评论数量
 	var count int64
-	db.Model(&Comment{}).Where("post_id = ?", testPost.ID).Count(&count)
+	if err := db.Model(&Comment{}).Where("post_id = ?", testPost.ID).Count(&count).Error; err != nil {
+		return fmt.Errorf("查询评论数量失败: %w", err)
+	}
 	fmt.Printf("删除前: 该文章的评论数量 = %d\n", count)
 
 	// 删除评论（会触发 AfterDelete 钩子）
-	if err := db.Delete(&testComment).Error; err != nil {
-		log.Printf("删除评论失败: %v\n", err)
-		return
+	if err := DeleteComment(db, &testComment); err != nil {
+		return fmt.Errorf("删除评论失败: %w", err)
 	}
 
 	// 重新查询文章，查看评论状态是否自动更新
-	db.First(&testPost, testPost.ID)
-	db.Model(&Comment{}).Where("post_id = ?", testPost.ID).Count(&count)
+	if err := db.First(&testPost, testPost.ID).Error; err != nil {
+		return fmt.Errorf("查询文章失败: %w", err)
+	}
+	if err := db.Model(&Comment{}).Where("post_id = ?", testPost.ID).Count(&count).Error; err != nil {
+		return fmt.Errorf("查询评论数量失败: %w", err)
+	}
 	fmt.Printf("删除后: 文章 '%s' 的评论状态 = '%s' (自动更新)\n", testPost.Title, testPost.CommentStatus)
 	fmt.Printf("删除后: 该文章的评论数量 = %d\n", count)
+	return nil
 }
@@ -0,0 +1,215 @@
+package advancedone
+
+import (
+	"errors"
+	"testing"
+
+	"gorm/fixtures"
+	"gorm/internal/testdb"
+	"gorm/scenario"
+
+	"gorm.io/gorm"
+)
+
+// setupBlog 创建表并插入 Setup 的测试数据
+func setupBlog(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := testdb.Open(t)
+	if err := (&Scenario{}).Setup(testdb.Context(), db); err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	return db
+}
+
+func findUser(t *testing.T, db *gorm.DB, username string) User {
+	t.Helper()
+	var u User
+	if err := db.Where("username = ?", username).First(&u).Error; err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestQueryUserPostsWithComments(t *testing.T) {
+	db := setupBlog(t)
+
+	user, err := QueryUserPostsWithComments(db, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(user.Posts) != 2 {
+		t.Fatalf("alice 的文章数 = %d, 期望 2", len(user.Posts))
+	}
+
+	comments := 0
+	for _, p := range user.Posts {
+		for _, c := range p.Comments {
+			comments++
+			if c.User.Username != "bob" {
+				t.Errorf("评论 %d 的评论者 = %q, 期望预加载 bob", c.ID, c.User.Username)
+			}
+		}
+	}
+	if comments != 3 {
+		t.Errorf("alice 文章下的评论数 = %d, 期望 3", comments)
+	}
+
+	if _, err := QueryUserPostsWithComments(db, "nobody"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("查询不存在的用户: err = %v, 期望 gorm.ErrRecordNotFound", err)
+	}
+}
+
+func TestQueryMostCommentedPost(t *testing.T) {
+	db := setupBlog(t)
+
+	got, err := QueryMostCommentedPost(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "GORM 入门教程" || got.CommentCount != 2 {
+		t.Errorf("评论最多的文章 = %s (%d 条评论), 期望 GORM 入门教程 (2 条评论)", got.Title, got.CommentCount)
+	}
+	if got.User.Username != "alice" || len(got.Comments) != 2 {
+		t.Errorf("预加载的作者 = %q, 评论数 = %d", got.User.Username, len(got.Comments))
+	}
+}
+
+func TestQueryMostCommentedPostWithoutComments(t *testing.T) {
+	db := setupBlog(t)
+	if err := db.Exec("DELETE FROM comments").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := QueryMostCommentedPost(db); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("err = %v, 期望 gorm.ErrRecordNotFound", err)
+	}
+}
+
+func TestPostBeforeCreateIncrementsPostCount(t *testing.T) {
+	db := setupBlog(t)
+	alice := findUser(t, db, "alice")
+	if alice.PostCount != 2 {
+		t.Fatalf("初始 PostCount = %d, 期望 2", alice.PostCount)
+	}
+
+	post := Post{Title: "新文章", Content: "内容", UserID: alice.ID}
+	if err := CreatePost(db, &post); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := findUser(t, db, "alice").PostCount; got != 3 {
+		t.Errorf("创建文章后 PostCount = %d, 期望 3", got)
+	}
+}
+
+func TestCommentAfterDeleteUpdatesCommentStatus(t *testing.T) {
+	db := setupBlog(t)
+
+	// GORM 入门教程 有两条评论：删除第一条后仍有评论，删除第二条后状态变为 无评论
+	var post Post
+	if err := db.Preload("Comments").Where("title = ?", "GORM 入门教程").First(&post).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(post.Comments) != 2 {
+		t.Fatalf("评论数 = %d, 期望 2", len(post.Comments))
+	}
+
+	status := func() string {
+		var p Post
+		if err := db.First(&p, post.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		return p.CommentStatus
+	}
+
+	if err := DeleteComment(db, &post.Comments[0]); err != nil {
+		t.Fatal(err)
+	}
+	if got := status(); got != "有评论" {
+		t.Errorf("删除一条评论后状态 = %q, 期望 有评论", got)
+	}
+
+	if err := DeleteComment(db, &post.Comments[1]); err != nil {
+		t.Fatal(err)
+	}
+	if got := status(); got != "无评论" {
+		t.Errorf("删除全部评论后状态 = %q, 期望 无评论", got)
+	}
+}
+
+func TestSetupUpsertsUserByEmail(t *testing.T) {
+	db := setupBlog(t)
+	alice := findUser(t, db, "alice")
+
+	// 已有用户改了用户名，测试数据中的邮箱仍然属于这个用户：按邮箱更新，不违反邮箱的唯一索引
+	if err := db.Model(&alice).Update("username", "alice-renamed").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := (&Scenario{}).Setup(testdb.Context(), db); err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	if got := findUser(t, db, "alice"); got.ID != alice.ID || got.Email != "alice@example.com" {
+		t.Errorf("alice = %+v, 期望更新原来的用户 %d", got, alice.ID)
+	}
+	var count int64
+	db.Model(&User{}).Count(&count)
+	if count != 2 {
+		t.Errorf("用户数 = %d, 期望 2", count)
+	}
+
+	// 用户名和邮箱分别属于不同的用户：明确报告冲突，不修改数据
+	bob := findUser(t, db, "bob")
+	if err := db.Model(&alice).Update("email", "old-alice@example.com").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&bob).Update("email", "alice@example.com").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := (&Scenario{}).Setup(testdb.Context(), db); !errors.Is(err, fixtures.ErrKeyConflict) {
+		t.Fatalf("Setup: err = %v, 期望 fixtures.ErrKeyConflict", err)
+	}
+	if got := findUser(t, db, "bob"); got.Email != "alice@example.com" {
+		t.Errorf("冲突时 bob 的邮箱 = %q, 期望不变", got.Email)
+	}
+}
+
+func TestScenario(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := testdb.Context()
+	s := &Scenario{Username: "bob"}
+
+	if err := s.Setup(ctx, db); err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	if err := s.Run(ctx, db); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+}
+
+func TestScenarioIsRepeatable(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := testdb.Context()
+	s := &Scenario{Username: "alice"}
+
+	for i := 0; i < 2; i++ {
+		if err := scenario.Execute(ctx, db, s); err != nil {
+			t.Fatalf("第 %d 次执行: %v", i+1, err)
+		}
+	}
+
+	// 重复执行不会删除或重复写入数据，钩子演示创建的数据已回滚
+	counts := map[string]int64{}
+	for table, model := range map[string]any{"users": &User{}, "posts": &Post{}, "comments": &Comment{}} {
+		var n int64
+		if err := db.Model(model).Count(&n).Error; err != nil {
+			t.Fatal(err)
+		}
+		counts[table] = n
+	}
+	if counts["users"] != 2 || counts["posts"] != 3 || counts["comments"] != 4 {
+		t.Errorf("重复执行后的记录数 = %v", counts)
+	}
+	if got := findUser(t, db, "alice").PostCount; got != 2 {
+		t.Errorf("alice 的 PostCount = %d, 期望 2", got)
+	}
+}
@@ -1,8 +1,12 @@
 package gormSql
 
 import (
+	"context"
 	"fmt"
 
+	"gorm/migrate"
+	"gorm/scenario"
+
 	"gorm.io/gorm"
 )
 
@@ -16,22 +20,46 @@ type Students struct {
 	Grade string `gorm:"type:varchar(50)"`
 }
 
-func Run(db *gorm.DB) {
-	// 自动迁移
-	db.AutoMigrate(&Students{})
+func init() {
+	scenario.Register(&Scenario{})
+}
+
+// Scenario 基本CRUD操作示例
+type Scenario struct{}
+
+func (*Scenario) Name() string        { return "students" }
+func (*Scenario) Description() string { return "题目1：基本CRUD操作" }
+
+// Setup 执行数据库迁移，确保 students 表存在
+func (*Scenario) Setup(ctx context.Context, db *gorm.DB) error {
+	_, err := migrate.Up(ctx, db)
+	return err
+}
+
+func (*Scenario) Teardown(context.Context, *gorm.DB) error { return nil }
+
+// Run 执行基本CRUD操作
+func (*Scenario) Run(ctx context.Context, db *gorm.DB) error {
+	db = db.WithContext(ctx)
 
 	// 1. 编写SQL语句向 students 表中插入一条新记录，学生姓名为 "张三"，年龄为 20，年级为 "三年级"。
-	student := Students{Name: "张三", Age: 20, Grade: "三年级"}
-	result := db.Create(&student)
+	// 按姓名查找已有记录，不存在时才插入，重复运行不会产生重复数据
+	var student Students
+	result := db.Where(Students{Name: "张三"}).Attrs(Students{Age: 20, Grade: "三年级"}).FirstOrCreate(&student)
 	if result.Error != nil {
-		fmt.Printf("插入学生记录失败: %v\n", result.Error)
-	} else {
+		return fmt.Errorf("插入学生记录失败: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
 		fmt.Printf("成功插入学生记录，ID: %d\n", student.ID)
+	} else {
+		fmt.Printf("学生记录已存在，ID: %d\n", student.ID)
 	}
 
 	// 2. 编写SQL语句查询 students 表中所有年龄大于 18 岁的学生信息。
 	var studentsAbove18 []Students
-	db.Where("age > ?", 18).Find(&studentsAbove18)
+	if err := db.Where("age > ?", 18).Find(&studentsAbove18).Error; err != nil {
+		return fmt.Errorf("查询学生记录失败: %w", err)
+	}
 	for _, s := range studentsAbove18 {
 		fmt.Printf("ID: %d, 姓名: %s, 年龄: %d, 年级: %s\n", s.ID, s.Name, s.Age, s.Grade)
 	}
@@ -55,4 +83,5 @@ func Run(db *gorm.DB) {
 	// 	fmt.Printf("成功删除%d条学生记录\n", result.RowsAffected)
 	// }
 
+	return nil
 }
@@ -0,0 +1,81 @@
+package gormSql
+
+import (
+	"context"
+	"testing"
+
+	"gorm/internal/testdb"
+)
+
+func TestScenario(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	s := &Scenario{}
+
+	if err := s.Setup(ctx, db); err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	// 重复运行不会重复插入
+	for i := 0; i < 2; i++ {
+		if err := s.Run(ctx, db); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+	}
+
+	var students []Students
+	if err := db.Where("name = ?", "张三").Find(&students).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(students) != 1 {
+		t.Fatalf("张三 的记录数 = %d, 期望 1", len(students))
+	}
+	if got := students[0]; got.Age != 20 || got.Grade != "三年级" {
+		t.Errorf("插入的记录 = %+v", got)
+	}
+}
+
+func TestStudentCRUD(t *testing.T) {
+	db := testdb.Open(t)
+	if err := (&Scenario{}).Setup(context.Background(), db); err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+
+	seed := []Students{
+		{Name: "张三", Age: 20, Grade: "三年级"},
+		{Name: "李四", Age: 14, Grade: "一年级"},
+		{Name: "王五", Age: 18, Grade: "二年级"},
+	}
+	if err := db.Create(&seed).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var above18 []Students
+	if err := db.Where("age > ?", 18).Find(&above18).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(above18) != 1 || above18[0].Name != "张三" {
+		t.Errorf("年龄大于 18 的学生 = %+v, 期望只有 张三", above18)
+	}
+
+	result := db.Model(&Students{}).Where("name = ?", "张三").Update("grade", "四年级")
+	if result.Error != nil || result.RowsAffected != 1 {
+		t.Fatalf("更新年级: rows=%d err=%v", result.RowsAffected, result.Error)
+	}
+	var zhang Students
+	if err := db.Where("name = ?", "张三").First(&zhang).Error; err != nil {
+		t.Fatal(err)
+	}
+	if zhang.Grade != "四年级" {
+		t.Errorf("张三 的年级 = %s, 期望 四年级", zhang.Grade)
+	}
+
+	result = db.Where("age < ?", 15).Delete(&Students{})
+	if result.Error != nil || result.RowsAffected != 1 {
+		t.Fatalf("删除学生: rows=%d err=%v", result.RowsAffected, result.Error)
+	}
+	var count int64
+	db.Model(&Students{}).Count(&count)
+	if count != 2 {
+		t.Errorf("删除后学生数量 = %d, 期望 2", count)
+	}
+}
@@ -0,0 +1,111 @@
+package gormSqlTwo
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"gorm/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AccountStatus 账户状态
+type AccountStatus string
+
+const (
+	// AccountActive 正常，可以转入和转出
+	AccountActive AccountStatus = "active"
+	// AccountFrozen 冻结，可以转入，不能转出
+	AccountFrozen AccountStatus = "frozen"
+	// AccountClosed 已销户，不能转入或转出，不能再恢复
+	AccountClosed AccountStatus = "closed"
+)
+
+// AccountStatusChange 账户状态变更记录，只追加不修改
+type AccountStatusChange struct {
+	ID         uint          `gorm:"primaryKey;autoIncrement" json:"id" label:"ID"`
+	AccountID  uint          `gorm:"index" json:"account_id" label:"账户"`
+	FromStatus AccountStatus `gorm:"type:varchar(20)" json:"from_status" label:"原状态"`
+	ToStatus   AccountStatus `gorm:"type:varchar(20)" json:"to_status" label:"新状态"`
+	Reason     string        `gorm:"type:varchar(255)" json:"reason" label:"原因"`
+	// Actor 执行变更的操作人
+	Actor     string    `gorm:"type:varchar(64)" json:"actor" label:"操作人"`
+	CreatedAt time.Time `json:"created_at" label:"时间"`
+}
+
+// FreezeAccount 冻结正常状态的账户，冻结后账户不能转出
+func FreezeAccount(db *gorm.DB, accountID uint, actor, reason string) error {
+	return changeStatus(db, accountID, AccountFrozen, actor, reason, AccountActive)
+}
+
+// UnfreezeAccount 解冻已冻结的账户
+func UnfreezeAccount(db *gorm.DB, accountID uint, actor, reason string) error {
+	return changeStatus(db, accountID, AccountActive, actor, reason, AccountFrozen)
+}
+
+// CloseAccount 销户，正常或冻结状态的账户余额为零时才能销户，否则返回 ErrBalanceNotZero
+func CloseAccount(db *gorm.DB, accountID uint, actor, reason string) error {
+	return changeStatus(db, accountID, AccountClosed, actor, reason, AccountActive, AccountFrozen)
+}
+
+// changeStatus 在一个事务中锁定账户，检查当前状态后修改状态并写入变更记录
+// 转账同样锁定账户后才检查状态，状态变更和转账不会交错。
+func changeStatus(db *gorm.DB, accountID uint, to AccountStatus, actor, reason string, from ...AccountStatus) error {
+	return database.Transaction(db, func(tx *gorm.DB) error {
+		var account Account
+		if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).First(&account, accountID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: 账户%d: %w", ErrAccountNotFound, accountID, err)
+			}
+			return fmt.Errorf("锁定账户 %d 失败: %w", accountID, err)
+		}
+		if !slices.Contains(from, account.Status) {
+			return fmt.Errorf("%w: 账户%d 的状态为 %s，不能变更为 %s", ErrInvalidStatusTransition, accountID, account.Status, to)
+		}
+		if to == AccountClosed && account.Balance != 0 {
+			return fmt.Errorf("%w: 账户%d 余额 %s", ErrBalanceNotZero, accountID, account.Balance)
+		}
+
+		previous := account.Status
+		if err := tx.Model(&account).Update("status", to).Error; err != nil {
+			return fmt.Errorf("更新账户 %d 的状态失败: %w", accountID, err)
+		}
+		change := AccountStatusChange{
+			AccountID:  accountID,
+			FromStatus: previous,
+			ToStatus:   to,
+			Reason:     truncate(reason, 255),
+			Actor:      truncate(actor, 64),
+		}
+		if err := tx.Create(&change).Error; err != nil {
+			return fmt.Errorf("记录账户 %d 的状态变更失败: %w", accountID, err)
+		}
+		return nil
+	})
+}
+
+// AccountStatusHistory 返回账户的状态变更记录，按变更顺序排列
+func AccountStatusHistory(db *gorm.DB, accountID uint) ([]AccountStatusChange, error) {
+	var changes []AccountStatusChange
+	if err := db.Where("account_id = ?", accountID).Order("id").Find(&changes).Error; err != nil {
+		return nil, fmt.Errorf("查询账户 %d 的状态变更失败: %w", accountID, err)
+	}
+	return changes, nil
+}
+
+// checkAccountStatus 检查转账双方的状态：已销户的账户不能转入或转出，冻结的账户不能转出
+func checkAccountStatus(from, to Account) error {
+	if from.Status == AccountClosed {
+		return fmt.Errorf("%w: 转出账户%d", ErrAccountClosed, from.ID)
+	}
+	if to.Status == AccountClosed {
+		return fmt.Errorf("%w: 转入账户%d", ErrAccountClosed, to.ID)
+	}
+	if from.Status == AccountFrozen {
+		return fmt.Errorf("%w: 转出账户%d", ErrAccountFrozen, from.ID)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package gormSqlTwo
+
+import (
+	"errors"
+	"testing"
+
+	"gorm/money"
+)
+
+func TestFreezeAccount(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(100), money.FromUnits(100))
+
+	if err := FreezeAccount(db, a.ID, "risk", "疑似盗用"); err != nil {
+		t.Fatal(err)
+	}
+	// 冻结的账户不能转出，可以转入
+	err := transferMoney(db, a.ID, b.ID, money.FromUnits(10))
+	var te *TransferError
+	if !errors.Is(err, ErrAccountFrozen) || !errors.As(err, &te) || te.TransactionID == 0 {
+		t.Errorf("err = %v, 期望 ErrAccountFrozen 且记录失败的交易", err)
+	}
+	if err := transferMoney(db, b.ID, a.ID, money.FromUnits(10)); err != nil {
+		t.Errorf("向冻结账户转入: %v", err)
+	}
+	if _, err := BatchTransfer(db, []TransferRequest{{FromAccountID: a.ID, ToAccountID: b.ID, Amount: 1}}); !errors.Is(err, ErrAccountFrozen) {
+		t.Errorf("批量转账 err = %v, 期望 ErrAccountFrozen", err)
+	}
+	if err := FreezeAccount(db, a.ID, "risk", "重复冻结"); !errors.Is(err, ErrInvalidStatusTransition) {
+		t.Errorf("重复冻结 err = %v, 期望 ErrInvalidStatusTransition", err)
+	}
+
+	if err := UnfreezeAccount(db, a.ID, "risk", "核实无误"); err != nil {
+		t.Fatal(err)
+	}
+	if err := transferMoney(db, a.ID, b.ID, money.FromUnits(10)); err != nil {
+		t.Errorf("解冻后转出: %v", err)
+	}
+	if err := UnfreezeAccount(db, a.ID, "risk", ""); !errors.Is(err, ErrInvalidStatusTransition) {
+		t.Errorf("解冻正常账户 err = %v, 期望 ErrInvalidStatusTransition", err)
+	}
+	if got := balanceOf(t, db, a.ID); got != money.FromUnits(100) {
+		t.Errorf("账户A余额 = %s, 期望 100.00", got)
+	}
+
+	history, err := AccountStatusHistory(db, a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []AccountStatusChange{
+		{FromStatus: AccountActive, ToStatus: AccountFrozen, Actor: "risk", Reason: "疑似盗用"},
+		{FromStatus: AccountFrozen, ToStatus: AccountActive, Actor: "risk", Reason: "核实无误"},
+	}
+	if len(history) != len(want) {
+		t.Fatalf("状态变更 %d 条, 期望 %d: %+v", len(history), len(want), history)
+	}
+	for i, w := range want {
+		h := history[i]
+		if h.AccountID != a.ID || h.FromStatus != w.FromStatus || h.ToStatus != w.ToStatus || h.Actor != w.Actor || h.Reason != w.Reason || h.CreatedAt.IsZero() {
+			t.Errorf("第 %d 条变更 = %+v, 期望 %+v", i+1, h, w)
+		}
+	}
+}
+
+func TestCloseAccount(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(100), 0)
+
+	if err := CloseAccount(db, a.ID, "ops", "客户申请"); !errors.Is(err, ErrBalanceNotZero) {
+		t.Fatalf("余额不为零时销户 err = %v, 期望 ErrBalanceNotZero", err)
+	}
+	if err := transferMoney(db, a.ID, b.ID, money.FromUnits(100)); err != nil {
+		t.Fatal(err)
+	}
+	if err := CloseAccount(db, a.ID, "ops", "客户申请"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 已销户的账户不能转入或转出，也不能恢复
+	if err := transferMoney(db, b.ID, a.ID, money.FromUnits(10)); !errors.Is(err, ErrAccountClosed) {
+		t.Errorf("向已销户账户转入 err = %v, 期望 ErrAccountClosed", err)
+	}
+	if err := transferMoney(db, a.ID, b.ID, money.FromUnits(10)); !errors.Is(err, ErrAccountClosed) {
+		t.Errorf("从已销户账户转出 err = %v, 期望 ErrAccountClosed", err)
+	}
+	for _, change := range []func() error{
+		func() error { return FreezeAccount(db, a.ID, "ops", "") },
+		func() error { return UnfreezeAccount(db, a.ID, "ops", "") },
+		func() error { return CloseAccount(db, a.ID, "ops", "") },
+	} {
+		if err := change(); !errors.Is(err, ErrInvalidStatusTransition) {
+			t.Errorf("变更已销户账户 err = %v, 期望 ErrInvalidStatusTransition", err)
+		}
+	}
+	if err := FreezeAccount(db, 9999, "ops", ""); !errors.Is(err, ErrAccountNotFound) {
+		t.Errorf("err = %v, 期望 ErrAccountNotFound", err)
+	}
+
+	// 冻结的账户余额为零时也可以销户
+	if err := FreezeAccount(db, b.ID, "ops", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := CloseAccount(db, b.ID, "ops", ""); !errors.Is(err, ErrBalanceNotZero) {
+		t.Errorf("err = %v, 期望 ErrBalanceNotZero", err)
+	}
+}
+
+func TestReverseTransferChecksAccountStatus(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(100), 0)
+	transaction, err := Transfer(db, TransferRequest{FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.FromUnits(30)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := FreezeAccount(db, b.ID, "risk", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReverseTransfer(db, transaction.ID, "测试"); !errors.Is(err, ErrAccountFrozen) {
+		t.Errorf("err = %v, 期望 ErrAccountFrozen", err)
+	}
+
+	// 冲正失败时原交易保持 completed，解冻后可以冲正
+	var original Transaction
+	if err := db.First(&original, transaction.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if original.Status != StatusCompleted {
+		t.Errorf("原交易状态 = %s, 期望 completed", original.Status)
+	}
+	if err := UnfreezeAccount(db, b.ID, "risk", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReverseTransfer(db, transaction.ID, "测试"); err != nil {
+		t.Errorf("解冻后冲正: %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package gormSqlTwo
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm/database"
+
+	"gorm.io/gorm"
+)
+
+// BatchError 批量转账中的一笔转账失败，整批已经回滚
+type BatchError struct {
+	// Index 失败的转账在请求中的下标（从 0 开始）
+	Index int
+	// Count 请求中转账的笔数
+	Count int
+	// Err 失败原因，为记录这笔转账的账户和金额的 *TransferError
+	Err error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("批量转账第 %d 笔（共 %d 笔）失败，整批已回滚: %v", e.Index+1, e.Count, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// BatchTransfer 在一个事务中执行一批转账，全部成功或全部回滚，返回与 legs 一一对应的交易记录
+// 每笔转账都写入一条 completed 状态的交易记录和一张凭证，同一个账户可以出现在多笔转账中，
+// 按请求顺序依次扣款，前面的转账入账的金额可以被后面的转账转出。
+//
+// 开始扣款前校验全部转账并按 ID 升序锁定涉及的所有账户，与单笔转账的加锁顺序一致。
+// 任何一笔失败时返回 *BatchError，Index 指出失败的转账，余额和交易记录都不变（不写入 failed 记录）；
+// 失败原因可以用 errors.Is 判断，例如 ErrInsufficientFunds、ErrAccountNotFound、ErrPolicyViolation；
+// 转账限制与单笔转账相同，每日累计上限包括同一批中前面的转账。
+// 批量转账不支持单笔的幂等键，IdempotencyKey 不为空时返回错误；没有指定渠道的转账记为 ChannelBatch。
+func BatchTransfer(db *gorm.DB, legs []TransferRequest) ([]Transaction, error) {
+	if len(legs) == 0 {
+		return nil, errors.New("批量转账没有转账请求")
+	}
+	ids := make([]uint, 0, 2*len(legs))
+	for i, leg := range legs {
+		if err := validateLeg(leg); err != nil {
+			return nil, batchError(i, legs, err)
+		}
+		ids = append(ids, leg.FromAccountID, leg.ToAccountID)
+	}
+
+	var results []Transaction
+	err := database.Transaction(db, func(tx *gorm.DB) error {
+		results = make([]Transaction, len(legs))
+
+		// 1. 先确认账户都存在，指出引用了不存在账户的转账
+		var existing []uint
+		if err := tx.Model(&Account{}).Where("id IN ?", ids).Pluck("id", &existing).Error; err != nil {
+			return fmt.Errorf("查询账户失败: %w", err)
+		}
+		found := make(map[uint]bool, len(existing))
+		for _, id := range existing {
+			found[id] = true
+		}
+		for i, leg := range legs {
+			for _, id := range []uint{leg.FromAccountID, leg.ToAccountID} {
+				if !found[id] {
+					return batchError(i, legs, fmt.Errorf("%w: 账户%d: %w", ErrAccountNotFound, id, gorm.ErrRecordNotFound))
+				}
+			}
+		}
+
+		// 2. 按 ID 顺序锁定全部账户
+		accounts, err := lockAccounts(tx, ids...)
+		if err != nil {
+			return err
+		}
+
+		// 3. 读取各转出账户的转账限制
+		policies := make(map[uint]*AccountPolicy)
+		for _, leg := range legs {
+			if policies[leg.FromAccountID] != nil {
+				continue
+			}
+			policy, err := EffectivePolicy(tx, accounts[leg.FromAccountID])
+			if err != nil {
+				return err
+			}
+			policies[leg.FromAccountID] = &policy
+		}
+
+		// 4. 依次写入交易记录、扣款、入账并标记为已完成
+		for i, leg := range legs {
+			transaction := newPendingTransaction(leg, accounts[leg.FromAccountID].Currency, ChannelBatch)
+			if err := tx.Create(&transaction).Error; err != nil {
+				return batchError(i, legs, fmt.Errorf("记录交易信息失败: %w", err))
+			}
+			description := fmt.Sprintf("批量转账 %d/%d 账户%d → 账户%d", i+1, len(legs), leg.FromAccountID, leg.ToAccountID)
+			if err := applyTransfer(tx, &transaction, accounts, policies[leg.FromAccountID], description); err != nil {
+				return batchError(i, legs, err)
+			}
+			if err := complete(tx, &transaction); err != nil {
+				return batchError(i, legs, err)
+			}
+			results[i] = transaction
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// validateLeg 校验批量转账中的一笔转账
+func validateLeg(leg TransferRequest) error {
+	if err := validateRequest(leg); err != nil {
+		return err
+	}
+	if leg.IdempotencyKey != "" {
+		return fmt.Errorf("批量转账不支持单笔的幂等键: %s", leg.IdempotencyKey)
+	}
+	return nil
+}
+
+// batchError 把第 i 笔转账的失败原因包装为 *BatchError
+func batchError(i int, legs []TransferRequest, err error) *BatchError {
+	leg := legs[i]
+	return &BatchError{
+		Index: i,
+		Count: len(legs),
+		Err:   &TransferError{Op: "批量转账", FromAccountID: leg.FromAccountID, ToAccountID: leg.ToAccountID, Amount: leg.Amount, Err: err},
+	}
+}
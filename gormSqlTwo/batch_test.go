@@ -0,0 +1,171 @@
+package gormSqlTwo
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm/money"
+)
+
+func TestBatchTransfer(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(500), money.FromUnits(0))
+	c, err := OpenAccount(db, money.USD, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SetExchangeRate(db, money.CNY, money.USD, money.MustParseRate("0.1385"), time.Now().Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	// 从 A 向 B 和 C 发放，B 再转出刚收到的金额
+	results, err := BatchTransfer(db, []TransferRequest{
+		{FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.FromUnits(300)},
+		{FromAccountID: a.ID, ToAccountID: c.ID, Amount: money.FromUnits(100)},
+		{FromAccountID: b.ID, ToAccountID: a.ID, Amount: money.FromUnits(50)},
+	})
+	if err != nil {
+		t.Fatalf("BatchTransfer: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("结果 %d 笔, 期望 3", len(results))
+	}
+	for i, r := range results {
+		if r.ID == 0 || r.Status != StatusCompleted || r.CompletedAt == nil {
+			t.Errorf("第 %d 笔: %+v, 期望已完成的交易记录", i, r)
+		}
+	}
+	if results[1].TargetAmount != money.FromCents(1385) || results[1].TargetCurrency != money.USD {
+		t.Errorf("跨币种转入 %s %s, 期望 13.85 USD", results[1].TargetAmount, results[1].TargetCurrency)
+	}
+
+	want := map[uint]money.Money{a.ID: money.FromUnits(150), b.ID: money.FromUnits(250), c.ID: money.FromCents(1385)}
+	for id, w := range want {
+		if got := balanceOf(t, db, id); got != w {
+			t.Errorf("账户%d 余额 = %s, 期望 %s", id, got, w)
+		}
+	}
+	if discrepancies, err := VerifyLedger(db); err != nil || len(discrepancies) != 0 {
+		t.Errorf("VerifyLedger = %+v, %v", discrepancies, err)
+	}
+}
+
+func TestBatchTransferRollsBackOnFailingLeg(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(100), money.FromUnits(0))
+
+	legs := []TransferRequest{
+		{FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.FromUnits(60)},
+		{FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.FromUnits(60)}, // 余额只剩 40
+		{FromAccountID: b.ID, ToAccountID: a.ID, Amount: money.FromUnits(10)},
+	}
+	_, err := BatchTransfer(db, legs)
+	var be *BatchError
+	if !errors.As(err, &be) || be.Index != 1 || be.Count != 3 {
+		t.Fatalf("err = %v, 期望第 2 笔失败的 *BatchError", err)
+	}
+	if !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("err = %v, 期望 ErrInsufficientFunds", err)
+	}
+	var te *TransferError
+	if !errors.As(err, &te) || te.Amount != money.FromUnits(60) || te.FromAccountID != a.ID {
+		t.Errorf("TransferError = %+v, 期望记录失败的转账", te)
+	}
+
+	// 第 1 笔也被回滚，没有写入交易记录和凭证
+	if got := balanceOf(t, db, a.ID); got != money.FromUnits(100) {
+		t.Errorf("账户A余额 = %s, 期望 100.00", got)
+	}
+	if got := balanceOf(t, db, b.ID); got != 0 {
+		t.Errorf("账户B余额 = %s, 期望 0.00", got)
+	}
+	var n int64
+	if err := db.Model(&Transaction{}).Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("交易记录 %d 条, 期望 0", n)
+	}
+	if discrepancies, err := VerifyLedger(db); err != nil || len(discrepancies) != 0 {
+		t.Errorf("VerifyLedger = %+v, %v", discrepancies, err)
+	}
+}
+
+func TestBatchTransferValidation(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(100), 0)
+
+	if _, err := BatchTransfer(db, nil); err == nil {
+		t.Error("空的批量转账应当失败")
+	}
+
+	tests := []struct {
+		name  string
+		leg   TransferRequest
+		index int
+		want  error
+	}{
+		{"金额为零", TransferRequest{FromAccountID: a.ID, ToAccountID: b.ID}, 1, ErrInvalidAmount},
+		{"同一账户", TransferRequest{FromAccountID: a.ID, ToAccountID: a.ID, Amount: 1}, 1, ErrSameAccount},
+		{"账户不存在", TransferRequest{FromAccountID: a.ID, ToAccountID: 9999, Amount: 1}, 1, ErrAccountNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			legs := []TransferRequest{{FromAccountID: a.ID, ToAccountID: b.ID, Amount: 1}, tt.leg}
+			_, err := BatchTransfer(db, legs)
+			var be *BatchError
+			if !errors.As(err, &be) || be.Index != tt.index {
+				t.Fatalf("err = %v, 期望第 %d 笔失败", err, tt.index+1)
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, 期望 %v", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := BatchTransfer(db, []TransferRequest{{FromAccountID: a.ID, ToAccountID: b.ID, Amount: 1, IdempotencyKey: "k"}}); err == nil {
+		t.Error("带幂等键的批量转账应当失败")
+	}
+	if got := balanceOf(t, db, a.ID); got != money.FromUnits(100) {
+		t.Errorf("账户A余额 = %s, 期望 100.00", got)
+	}
+}
+
+func TestBatchTransferConcurrentOpposingBatches(t *testing.T) {
+	db, ids := setupFileAccounts(t, 4, money.FromUnits(1000))
+
+	// 两组批量转账以相反的顺序涉及相同的账户，按 ID 顺序加锁不会死锁
+	forward := make([]TransferRequest, 0, len(ids)-1)
+	backward := make([]TransferRequest, 0, len(ids)-1)
+	for i := 0; i+1 < len(ids); i++ {
+		forward = append(forward, TransferRequest{FromAccountID: ids[i], ToAccountID: ids[i+1], Amount: money.FromUnits(1)})
+		backward = append(backward, TransferRequest{FromAccountID: ids[len(ids)-1-i], ToAccountID: ids[len(ids)-2-i], Amount: money.FromUnits(1)})
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 10; i++ {
+		for _, legs := range [][]TransferRequest{forward, backward} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := BatchTransfer(db, legs); err != nil {
+					errs <- err
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	// 每组转账后首尾账户各变动 1 元，两组相互抵消
+	for _, id := range ids {
+		if got := balanceOf(t, db, id); got != money.FromUnits(1000) {
+			t.Errorf("账户%d 余额 = %s, 期望 1000.00", id, got)
+		}
+	}
+	if discrepancies, err := VerifyLedger(db); err != nil || len(discrepancies) != 0 {
+		t.Errorf("VerifyLedger = %+v, %v", discrepancies, err)
+	}
+}
@@ -0,0 +1,78 @@
+package gormSqlTwo
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm/money"
+)
+
+// 转账相关的错误
+// Transfer 和 ReverseTransfer 返回 *TransferError，失败原因通过 Unwrap 保留，调用方用 errors.Is 判断下面的哨兵错误，
+// 也可以判断底层的 gorm.ErrRecordNotFound 等错误。
+var (
+	// ErrInvalidAmount 金额不是正数，或换算后不足最小货币单位
+	ErrInvalidAmount = errors.New("无效的转账金额")
+	// ErrSameAccount 转出和转入是同一个账户
+	ErrSameAccount = errors.New("转出和转入账户相同")
+	// ErrAccountNotFound 账户不存在
+	ErrAccountNotFound = errors.New("账户不存在")
+	// ErrAccountFrozen 转出账户已冻结
+	ErrAccountFrozen = errors.New("账户已冻结")
+	// ErrAccountClosed 转出或转入账户已销户
+	ErrAccountClosed = errors.New("账户已销户")
+	// ErrInsufficientFunds 转出账户余额不足
+	ErrInsufficientFunds = errors.New("余额不足，无法完成转账")
+	// ErrIdempotencyConflict 幂等键已经用于参数不同的转账
+	ErrIdempotencyConflict = errors.New("幂等键已用于参数不同的转账")
+	// ErrTransferInProgress 幂等键对应的转账仍是 pending 状态：同一个键的另一个请求正在处理，
+	// 或者处理它的进程在扣款前退出（见 ExpirePendingTransfers）；调用方稍后用同一个键重试
+	ErrTransferInProgress = errors.New("同一个幂等键的转账正在处理中")
+	// ErrTransferFailed 幂等键对应的转账已经失败：重试返回原来的失败结果，不会再次执行，重新转账需要使用新的幂等键
+	ErrTransferFailed = errors.New("同一个幂等键的转账已失败")
+	// ErrNotReversible 只有已完成的转账可以冲正
+	ErrNotReversible = errors.New("转账不能冲正")
+	// ErrNoExchangeRate 转账时货币对没有已生效的汇率
+	ErrNoExchangeRate = errors.New("没有可用的汇率")
+	// ErrPolicyViolation 转账违反转出账户的转账限制，具体的限制见 *PolicyViolation
+	ErrPolicyViolation = errors.New("违反转账限制")
+	// ErrUnbalancedEntry 凭证中某个币种的分录金额之和不为零
+	ErrUnbalancedEntry = errors.New("凭证借贷不平衡")
+)
+
+// 定时转账的错误
+var (
+	// ErrScheduledTransferNotFound 定时转账不存在
+	ErrScheduledTransferNotFound = errors.New("定时转账不存在")
+	// ErrScheduleNotActive 定时转账已结束或已取消
+	ErrScheduleNotActive = errors.New("定时转账不是生效状态")
+)
+
+// 账户状态变更的错误，由 FreezeAccount、UnfreezeAccount 和 CloseAccount 返回
+var (
+	// ErrInvalidStatusTransition 账户当前的状态不允许这次变更，例如解冻未冻结的账户
+	ErrInvalidStatusTransition = errors.New("账户状态不允许这次变更")
+	// ErrBalanceNotZero 销户时账户余额不为零
+	ErrBalanceNotZero = errors.New("账户余额不为零，不能销户")
+)
+
+// TransferError 转账或冲正失败，记录涉及的账户和金额
+type TransferError struct {
+	// Op 失败的操作："转账"、"冲正" 或 "批量转账"
+	Op string
+	// TransactionID 已写入的交易记录（转账标记为 failed 的记录、被冲正的原交易），没有时为 0
+	TransactionID uint
+	FromAccountID uint
+	ToAccountID   uint
+	Amount        money.Money
+	// Err 失败原因
+	Err error
+}
+
+func (e *TransferError) Error() string {
+	return fmt.Sprintf("%s 账户%d → 账户%d（%s）失败: %v", e.Op, e.FromAccountID, e.ToAccountID, e.Amount, e.Err)
+}
+
+func (e *TransferError) Unwrap() error {
+	return e.Err
+}
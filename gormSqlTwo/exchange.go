@@ -0,0 +1,51 @@
+package gormSqlTwo
+
+import (
+	"fmt"
+	"time"
+
+	"gorm/money"
+
+	"gorm.io/gorm"
+)
+
+// ExchangeRate 汇率表：1 单位 BaseCurrency 兑换 Rate 单位 QuoteCurrency
+// 汇率从 EffectiveAt 起生效，直到同一货币对的下一条汇率生效。两个方向的汇率分别设置（买入价和卖出价不同）。
+type ExchangeRate struct {
+	ID            uint           `gorm:"primaryKey;autoIncrement"`
+	BaseCurrency  money.Currency `gorm:"type:char(3)"`
+	QuoteCurrency money.Currency `gorm:"type:char(3)"`
+	Rate          money.Rate     `gorm:"type:decimal(18,6)"`
+	EffectiveAt   time.Time
+}
+
+// SetExchangeRate 新增一条从 effectiveAt 起生效的汇率
+func SetExchangeRate(db *gorm.DB, base, quote money.Currency, rate money.Rate, effectiveAt time.Time) (*ExchangeRate, error) {
+	if !base.Valid() || !quote.Valid() || base == quote {
+		return nil, fmt.Errorf("无效的货币对 %s/%s", base, quote)
+	}
+	if rate <= 0 {
+		return nil, fmt.Errorf("汇率必须大于零: %s", rate)
+	}
+	r := ExchangeRate{BaseCurrency: base, QuoteCurrency: quote, Rate: rate, EffectiveAt: effectiveAt.UTC()}
+	if err := db.Create(&r).Error; err != nil {
+		return nil, fmt.Errorf("保存汇率 %s/%s 失败: %w", base, quote, err)
+	}
+	return &r, nil
+}
+
+// LookupRate 返回 at 时刻货币对 base/quote 生效的汇率，没有时返回 ErrNoExchangeRate
+func LookupRate(db *gorm.DB, base, quote money.Currency, at time.Time) (*ExchangeRate, error) {
+	var r ExchangeRate
+	result := db.Where("base_currency = ? AND quote_currency = ? AND effective_at <= ?", base, quote, at.UTC()).
+		Order("effective_at DESC").
+		Limit(1).
+		Find(&r)
+	if result.Error != nil {
+		return nil, fmt.Errorf("查询汇率 %s/%s 失败: %w", base, quote, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("%w: %s/%s（%s）", ErrNoExchangeRate, base, quote, at.Format(time.RFC3339))
+	}
+	return &r, nil
+}
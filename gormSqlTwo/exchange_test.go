@@ -0,0 +1,144 @@
+package gormSqlTwo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm/internal/testdb"
+	"gorm/money"
+
+	"gorm.io/gorm"
+)
+
+// setupCurrencyAccounts 创建一个人民币账户和一个美元账户
+func setupCurrencyAccounts(t *testing.T, cny, usd money.Money) (*gorm.DB, *Account, *Account) {
+	t.Helper()
+	db := testdb.Open(t)
+	if err := (&Scenario{}).Setup(context.Background(), db); err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	a, err := OpenAccount(db, money.CNY, cny)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := OpenAccount(db, money.USD, usd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, a, b
+}
+
+func TestLookupRate(t *testing.T) {
+	db, _, _ := setupCurrencyAccounts(t, 0, 0)
+	now := time.Now()
+
+	if _, err := SetExchangeRate(db, money.CNY, money.USD, money.MustParseRate("0.14"), now.Add(-48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SetExchangeRate(db, money.CNY, money.USD, money.MustParseRate("0.138"), now.Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	// 尚未生效的汇率
+	if _, err := SetExchangeRate(db, money.CNY, money.USD, money.MustParseRate("0.2"), now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		at   time.Time
+		want string
+	}{
+		{now, "0.138000"},
+		{now.Add(-24 * time.Hour), "0.140000"},
+		{now.Add(2 * time.Hour), "0.200000"},
+	}
+	for _, tt := range tests {
+		r, err := LookupRate(db, money.CNY, money.USD, tt.at)
+		if err != nil || r.Rate.String() != tt.want {
+			t.Errorf("LookupRate(%s) = %v, %v, 期望 %s", tt.at, r, err, tt.want)
+		}
+	}
+
+	if _, err := LookupRate(db, money.CNY, money.USD, now.Add(-72*time.Hour)); !errors.Is(err, ErrNoExchangeRate) {
+		t.Errorf("生效之前: err = %v, 期望 ErrNoExchangeRate", err)
+	}
+	// 汇率不按反方向推算
+	if _, err := LookupRate(db, money.USD, money.CNY, now); !errors.Is(err, ErrNoExchangeRate) {
+		t.Errorf("反方向: err = %v, 期望 ErrNoExchangeRate", err)
+	}
+	if _, err := SetExchangeRate(db, money.CNY, money.CNY, money.MustParseRate("1"), now); err == nil {
+		t.Error("相同货币的汇率应当报错")
+	}
+}
+
+func TestTransferCrossCurrency(t *testing.T) {
+	db, a, b := setupCurrencyAccounts(t, money.FromUnits(1000), money.FromUnits(10))
+	if _, err := SetExchangeRate(db, money.CNY, money.USD, money.MustParseRate("0.1385"), time.Now().Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	// 123.45 × 0.1385 = 17.097825，舍入到 17.10
+	tx, err := Transfer(db, TransferRequest{FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.MustParse("123.45")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Currency != money.CNY || tx.TargetCurrency != money.USD || tx.TargetAmount != money.MustParse("17.10") ||
+		tx.ExchangeRate == nil || *tx.ExchangeRate != money.MustParseRate("0.1385") {
+		t.Errorf("交易记录 = %+v", tx)
+	}
+	var reloaded Transaction
+	if err := db.First(&reloaded, tx.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.TargetAmount != tx.TargetAmount || reloaded.ExchangeRate == nil || *reloaded.ExchangeRate != *tx.ExchangeRate {
+		t.Errorf("保存的交易记录 = %+v", reloaded)
+	}
+	if got := balanceOf(t, db, a.ID); got != money.MustParse("876.55") {
+		t.Errorf("人民币账户余额 = %s, 期望 876.55", got)
+	}
+	if got := balanceOf(t, db, b.ID); got != money.MustParse("27.10") {
+		t.Errorf("美元账户余额 = %s, 期望 27.10", got)
+	}
+
+	// 冲正按原金额退回，不按当前汇率重新换算
+	if _, err := SetExchangeRate(db, money.USD, money.CNY, money.MustParseRate("8"), time.Now().Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReverseTransfer(db, tx.ID, "退款"); err != nil {
+		t.Fatal(err)
+	}
+	if got := balanceOf(t, db, a.ID); got != money.FromUnits(1000) {
+		t.Errorf("冲正后人民币账户余额 = %s, 期望 1000.00", got)
+	}
+	if got := balanceOf(t, db, b.ID); got != money.FromUnits(10) {
+		t.Errorf("冲正后美元账户余额 = %s, 期望 10.00", got)
+	}
+
+	if discrepancies, err := VerifyLedger(db); err != nil || len(discrepancies) != 0 {
+		t.Errorf("VerifyLedger = %+v, %v", discrepancies, err)
+	}
+}
+
+func TestTransferCrossCurrencyWithoutRate(t *testing.T) {
+	db, a, b := setupCurrencyAccounts(t, money.FromUnits(1000), 0)
+	// 只有尚未生效的汇率
+	if _, err := SetExchangeRate(db, money.CNY, money.USD, money.MustParseRate("0.14"), time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Transfer(db, TransferRequest{FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.FromUnits(100)})
+	if !errors.Is(err, ErrNoExchangeRate) {
+		t.Fatalf("err = %v, 期望 ErrNoExchangeRate", err)
+	}
+	if got := balanceOf(t, db, a.ID); got != money.FromUnits(1000) {
+		t.Errorf("人民币账户余额 = %s, 期望不变", got)
+	}
+	var failed Transaction
+	if err := db.Last(&failed).Error; err != nil {
+		t.Fatal(err)
+	}
+	if failed.Status != StatusFailed {
+		t.Errorf("交易状态 = %s, 期望 failed", failed.Status)
+	}
+}
@@ -1,10 +1,22 @@
+// Package gormSqlTwo 转账示例：账户、复式记账分录、转账与冲正、批量和定时转账、对账单
 package gormSqlTwo
 
 import (
+	"context"
 	"errors"
+	"flag"
 	"fmt"
+	"slices"
+	"time"
+	"unicode/utf8"
+
+	"gorm/database"
+	"gorm/migrate"
+	"gorm/money"
+	"gorm/scenario"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // 题目2：事务语句
@@ -14,132 +26,757 @@ import (
 // 编写一个事务，实现从账户 A 向账户 B 转账 100 元的操作。在事务中，需要先检查账户 A 的余额是否足够，
 // 如果足够则从账户 A 扣除 100 元，向账户 B 增加 100 元，并在 transactions 表中记录该笔转账信息。如果余额不足，则回滚事务。
 
-// Account 账户表
+// 金额使用 money.Money（以分为单位的整数），避免 float64 累加和比较时的舍入误差
+
+// Account 账户表，余额以账户币种计
 type Account struct {
-	ID      uint    `gorm:"primaryKey;autoIncrement"`
-	Balance float64 `gorm:"type:decimal(10,2)"`
+	ID       uint           `gorm:"primaryKey;autoIncrement"`
+	Balance  money.Money    `gorm:"type:decimal(10,2)"`
+	Currency money.Currency `gorm:"type:char(3)"`
+	// Type 账户类型，用于按类型配置转账限制（AccountPolicy）
+	Type   string        `gorm:"type:varchar(20)"`
+	Status AccountStatus `gorm:"type:varchar(20)"`
 }
 
+// TransferStatus 转账状态
+type TransferStatus string
+
+const (
+	// StatusPending 已受理，尚未完成扣款和入账
+	StatusPending TransferStatus = "pending"
+	// StatusCompleted 已完成
+	StatusCompleted TransferStatus = "completed"
+	// StatusFailed 失败，余额没有变动，Reason 记录失败原因
+	StatusFailed TransferStatus = "failed"
+	// StatusReversed 已被冲正，冲正交易的 ReversalOfID 指向这笔交易
+	StatusReversed TransferStatus = "reversed"
+)
+
 // Transaction 交易记录表
 type Transaction struct {
-	ID            uint    `gorm:"primaryKey;autoIncrement"`
-	FromAccountID uint    `gorm:"column:from_account_id"`
-	ToAccountID   uint    `gorm:"column:to_account_id"`
-	Amount        float64 `gorm:"type:decimal(10,2)"`
+	ID            uint `gorm:"primaryKey;autoIncrement" json:"id" label:"ID"`
+	FromAccountID uint `gorm:"column:from_account_id;index:idx_transactions_from_account_created_at" json:"from_account_id" label:"转出账户"`
+	ToAccountID   uint `gorm:"column:to_account_id;index:idx_transactions_to_account_created_at" json:"to_account_id" label:"转入账户"`
+	// Amount 转出金额，以 Currency（转出账户币种）计
+	Amount   money.Money    `gorm:"type:decimal(10,2)" json:"amount" label:"金额"`
+	Currency money.Currency `gorm:"type:char(3)" json:"currency" label:"币种"`
+	// TargetAmount 转入金额，以 TargetCurrency（转入账户币种）计；同币种转账时等于 Amount
+	TargetAmount   money.Money    `gorm:"type:decimal(10,2)" json:"target_amount" label:"到账金额"`
+	TargetCurrency money.Currency `gorm:"type:char(3)" json:"target_currency" label:"到账币种"`
+	// ExchangeRate 跨币种转账使用的汇率（1 单位 Currency 兑换的 TargetCurrency），同币种转账为 NULL
+	ExchangeRate *money.Rate `gorm:"type:decimal(18,6)" json:"exchange_rate,omitempty" label:"汇率"`
+	// IdempotencyKey 客户端提供的幂等键（唯一），没有提供时为 NULL
+	IdempotencyKey *string        `gorm:"type:varchar(64);uniqueIndex:idx_transactions_idempotency_key" json:"idempotency_key,omitempty" label:"幂等键"`
+	Status         TransferStatus `gorm:"type:varchar(20)" json:"status" label:"状态"`
+	// Reason 失败原因或冲正原因
+	Reason string `gorm:"type:varchar(255)" json:"reason,omitempty" label:"原因"`
+	// Memo 发起人填写的备注
+	Memo string `gorm:"type:varchar(255)" json:"memo,omitempty" label:"备注"`
+	// InitiatorID 发起转账的用户或系统
+	InitiatorID string  `gorm:"type:varchar(64);index:idx_transactions_initiator_created_at" json:"initiator_id,omitempty" label:"发起人"`
+	Channel     Channel `gorm:"type:varchar(20);index:idx_transactions_channel_created_at" json:"channel" label:"渠道"`
+	// CreatedAt 受理时间，CompletedAt 完成时间，均以 UTC 保存
+	CreatedAt   time.Time  `gorm:"index:idx_transactions_initiator_created_at;index:idx_transactions_channel_created_at;index:idx_transactions_from_account_created_at;index:idx_transactions_to_account_created_at;index" json:"created_at" label:"创建时间"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" label:"完成时间"`
+	// ReversalOfID 冲正交易指向被冲正的原交易
+	ReversalOfID *uint `gorm:"uniqueIndex:idx_transactions_reversal_of_id" json:"reversal_of_id,omitempty" label:"冲正原交易"`
+}
+
+// Channel 转账渠道
+type Channel string
+
+const (
+	// ChannelAPI 通过 Transfer 发起的单笔转账，TransferRequest 没有指定渠道时的默认值
+	ChannelAPI Channel = "api"
+	// ChannelBatch 批量转账
+	ChannelBatch Channel = "batch"
+	// ChannelAdmin 管理后台发起的操作，例如冲正
+	ChannelAdmin Channel = "admin"
+	// ChannelScheduled 定时转账执行器按计划发起的转账
+	ChannelScheduled Channel = "scheduled"
+)
+
+// Valid 是否为支持的渠道
+func (c Channel) Valid() bool {
+	return c == ChannelAPI || c == ChannelBatch || c == ChannelAdmin || c == ChannelScheduled
+}
+
+// TransferRequest 转账请求
+type TransferRequest struct {
+	FromAccountID uint
+	ToAccountID   uint
+	// Amount 转出金额，以转出账户的币种计
+	Amount money.Money
+	// IdempotencyKey 客户端提供的幂等键，为空时不做幂等检查
+	// 调用方超时后使用同一个键重试，不会重复转账。
+	IdempotencyKey string
+	// Memo 备注，最多 255 个字符
+	Memo string
+	// InitiatorID 发起人，最多 64 个字符
+	InitiatorID string
+	// Channel 渠道，为空时 Transfer 使用 ChannelAPI，BatchTransfer 使用 ChannelBatch
+	Channel Channel
+}
+
+func init() {
+	scenario.Register(&Scenario{Amount: money.FromUnits(100)})
+}
+
+// Scenario 银行转账事务示例
+type Scenario struct {
+	// Amount 从账户A向账户B转账的金额
+	Amount money.Money
+}
+
+func (*Scenario) Name() string        { return "transfer" }
+func (*Scenario) Description() string { return "题目2：事务语句（银行转账）" }
+
+// BindFlags 注册转账金额参数
+func (s *Scenario) BindFlags(fs *flag.FlagSet) {
+	fs.Var(&s.Amount, "amount", "transfer: 转账金额，最多两位小数")
+}
+
+// Setup 执行数据库迁移，确保 accounts 和 transactions 表存在
+func (*Scenario) Setup(ctx context.Context, db *gorm.DB) error {
+	_, err := migrate.Up(ctx, db)
+	return err
 }
 
+func (*Scenario) Teardown(context.Context, *gorm.DB) error { return nil }
+
 // Run 执行转账事务示例
-func Run(db *gorm.DB) {
-	// 自动迁移创建表
-	db.AutoMigrate(&Account{}, &Transaction{})
+func (s *Scenario) Run(ctx context.Context, db *gorm.DB) error {
+	db = db.WithContext(ctx)
+	amount := s.Amount
 
 	fmt.Println("=== 银行转账事务示例 ===")
 
-	// 创建测试账户
-	accountA := Account{Balance: 500.00} // 账户A初始余额500元
-	accountB := Account{Balance: 300.00} // 账户B初始余额300元
-
-	db.Create(&accountA)
-	db.Create(&accountB)
+	// 创建测试账户，初始余额记为从外部账户存入的凭证
+	accountA, err := OpenAccount(db, money.CNY, money.FromUnits(500)) // 账户A初始余额500元
+	if err != nil {
+		return fmt.Errorf("创建账户A失败: %w", err)
+	}
+	accountB, err := OpenAccount(db, money.CNY, money.FromUnits(300)) // 账户B初始余额300元
+	if err != nil {
+		return fmt.Errorf("创建账户B失败: %w", err)
+	}
 
 	fmt.Printf("转账前账户余额:\n")
-	fmt.Printf("账户A (ID: %d): %.2f 元\n", accountA.ID, accountA.Balance)
-	fmt.Printf("账户B (ID: %d): %.2f 元\n", accountB.ID, accountB.Balance)
+	fmt.Printf("账户A (ID: %d): %s 元\n", accountA.ID, accountA.Balance)
+	fmt.Printf("账户B (ID: %d): %s 元\n", accountB.ID, accountB.Balance)
 
-	// 执行转账事务：从账户A向账户B转账100元
-	fmt.Println("\n执行转账事务：从账户A向账户B转账100元...")
-	err := transferMoney(db, accountA.ID, accountB.ID, 100.00)
+	// 执行转账事务：从账户A向账户B转账
+	fmt.Printf("\n执行转账事务：从账户A向账户B转账%s元...\n", amount)
+	req := TransferRequest{
+		FromAccountID:  accountA.ID,
+		ToAccountID:    accountB.ID,
+		Amount:         amount,
+		IdempotencyKey: fmt.Sprintf("transfer-demo-%d-%d", accountA.ID, accountB.ID),
+		Memo:           "演示转账",
+		InitiatorID:    "demo",
+	}
+	transaction, err := Transfer(db, req)
 	if err != nil {
-		fmt.Printf("转账失败: %v\n", err)
-	} else {
-		fmt.Println("转账成功!")
+		return fmt.Errorf("转账失败: %w", err)
+	}
+	fmt.Printf("转账成功! 交易ID: %d\n", transaction.ID)
+
+	// 模拟调用方超时后使用同一个幂等键重试：返回原来的交易记录，不会再次转账
+	retried, err := Transfer(db, req)
+	if err != nil {
+		return fmt.Errorf("重试转账失败: %w", err)
+	}
+	if retried.ID != transaction.ID {
+		return fmt.Errorf("使用同一个幂等键重试产生了新的交易 %d", retried.ID)
 	}
+	fmt.Printf("使用幂等键 %s 重试，返回原交易 %d，没有重复转账\n", req.IdempotencyKey, retried.ID)
 
 	// 查询并显示转账后余额
 	var updatedAccountA, updatedAccountB Account
-	db.First(&updatedAccountA, accountA.ID)
-	db.First(&updatedAccountB, accountB.ID)
+	if err := db.First(&updatedAccountA, accountA.ID).Error; err != nil {
+		return fmt.Errorf("查询账户A失败: %w", err)
+	}
+	if err := db.First(&updatedAccountB, accountB.ID).Error; err != nil {
+		return fmt.Errorf("查询账户B失败: %w", err)
+	}
 
 	fmt.Printf("\n转账后账户余额:\n")
-	fmt.Printf("账户A (ID: %d): %.2f 元\n", updatedAccountA.ID, updatedAccountA.Balance)
-	fmt.Printf("账户B (ID: %d): %.2f 元\n", updatedAccountB.ID, updatedAccountB.Balance)
+	fmt.Printf("账户A (ID: %d): %s 元\n", updatedAccountA.ID, updatedAccountA.Balance)
+	fmt.Printf("账户B (ID: %d): %s 元\n", updatedAccountB.ID, updatedAccountB.Balance)
+
+	// 演示余额不足的情况：转账失败，余额不变，交易记录标记为 failed
+	overdraft := updatedAccountB.Balance + money.FromUnits(100)
+	fmt.Printf("\n尝试从账户B向账户A转账%s元（余额不足）...\n", overdraft)
+	err = transferMoney(db, updatedAccountB.ID, updatedAccountA.ID, overdraft)
+	if !errors.Is(err, ErrInsufficientFunds) {
+		return fmt.Errorf("余额不足的转账没有被拒绝: %w", err)
+	}
+	fmt.Println(err)
+
+	// 冲正第一笔转账：金额从账户B退回账户A，再次冲正会被拒绝
+	fmt.Printf("\n冲正交易 %d...\n", transaction.ID)
+	reversal, err := ReverseTransfer(db, transaction.ID, "演示冲正")
+	if err != nil {
+		return fmt.Errorf("冲正失败: %w", err)
+	}
+	fmt.Printf("冲正成功! 冲正交易ID: %d\n", reversal.ID)
+	if _, err := ReverseTransfer(db, transaction.ID, "重复冲正"); !errors.Is(err, ErrNotReversible) {
+		return fmt.Errorf("重复冲正没有被拒绝: %w", err)
+	}
+	fmt.Printf("再次冲正交易 %d 被拒绝\n", transaction.ID)
 
-	// 显示交易记录
+	// 冻结账户B：冻结期间不能转出，解冻后恢复
+	if err := FreezeAccount(db, accountB.ID, "demo", "演示冻结"); err != nil {
+		return err
+	}
+	if err := transferMoney(db, accountB.ID, accountA.ID, amount); !errors.Is(err, ErrAccountFrozen) {
+		return fmt.Errorf("冻结账户的转账没有被拒绝: %w", err)
+	}
+	fmt.Printf("\n账户B已冻结，从账户B转出被拒绝\n")
+	if err := UnfreezeAccount(db, accountB.ID, "demo", "演示解冻"); err != nil {
+		return err
+	}
+	fmt.Println("账户B已解冻")
+
+	// 跨币种转账：按当前生效的汇率把人民币换算为美元
+	accountC, err := OpenAccount(db, money.USD, 0)
+	if err != nil {
+		return fmt.Errorf("创建美元账户失败: %w", err)
+	}
+	rate := money.MustParseRate("0.1385")
+	if _, err := SetExchangeRate(db, money.CNY, money.USD, rate, time.Now()); err != nil {
+		return err
+	}
+	fmt.Printf("\n从账户A向美元账户C (ID: %d) 转账%s元，汇率 CNY/USD = %s...\n", accountC.ID, amount, rate)
+	fx, err := Transfer(db, TransferRequest{FromAccountID: accountA.ID, ToAccountID: accountC.ID, Amount: amount})
+	if err != nil {
+		return fmt.Errorf("跨币种转账失败: %w", err)
+	}
+	fmt.Printf("转账成功! 交易ID: %d, 账户C收到 %s %s\n", fx.ID, fx.TargetAmount, fx.TargetCurrency)
+
+	// 批量转账：从账户A向账户B和C各转账一笔，全部成功或全部回滚
+	fmt.Printf("\n批量转账：从账户A向账户B和账户C各转账%s元...\n", amount)
+	batch, err := BatchTransfer(db, []TransferRequest{
+		{FromAccountID: accountA.ID, ToAccountID: accountB.ID, Amount: amount},
+		{FromAccountID: accountA.ID, ToAccountID: accountC.ID, Amount: amount},
+	})
+	if err != nil {
+		return fmt.Errorf("批量转账失败: %w", err)
+	}
+	for i, t := range batch {
+		fmt.Printf("第 %d 笔: 交易ID %d, 到账 %s %s\n", i+1, t.ID, t.TargetAmount, t.TargetCurrency)
+	}
+
+	// 其中一笔余额不足时整批回滚，错误指出失败的转账
+	if err := db.First(&updatedAccountB, accountB.ID).Error; err != nil {
+		return fmt.Errorf("查询账户B失败: %w", err)
+	}
+	_, err = BatchTransfer(db, []TransferRequest{
+		{FromAccountID: accountA.ID, ToAccountID: accountB.ID, Amount: amount},
+		{FromAccountID: accountB.ID, ToAccountID: accountA.ID, Amount: updatedAccountB.Balance + amount + money.FromCents(1)},
+	})
+	var be *BatchError
+	if !errors.As(err, &be) || !errors.Is(err, ErrInsufficientFunds) {
+		return fmt.Errorf("余额不足的批量转账没有被拒绝: %w", err)
+	}
+	fmt.Println(err)
+
+	// 定时转账：每月从账户A向账户B转账，第一期立即到期；重复执行不会重复转账
+	standing, err := CreateScheduledTransfer(db, ScheduledTransfer{
+		FromAccountID: accountA.ID,
+		ToAccountID:   accountB.ID,
+		Amount:        amount,
+		Memo:          "每月定存",
+		InitiatorID:   req.InitiatorID,
+		Schedule:      "@monthly",
+		NextRunAt:     time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\n定时转账 %d（%s）：每期从账户A向账户B转账%s元...\n", standing.ID, standing.Schedule, amount)
+	for range 2 {
+		runs, err := RunDueTransfers(db, time.Now())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("执行到期的定时转账: %d 期\n", len(runs))
+	}
+	if err := CancelScheduledTransfer(db, standing.ID); err != nil {
+		return err
+	}
+
+	// 显示两个账户的交易记录
 	var transactions []Transaction
-	db.Find(&transactions)
+	demoAccounts := []uint{accountA.ID, accountB.ID, accountC.ID}
+	if err := db.Where("from_account_id IN ? OR to_account_id IN ?", demoAccounts, demoAccounts).
+		Order("id").Find(&transactions).Error; err != nil {
+		return fmt.Errorf("查询交易记录失败: %w", err)
+	}
 	fmt.Println("\n交易记录:")
 	for _, t := range transactions {
-		fmt.Printf("ID: %d, 从账户%d向账户%d转账%.2f元\n",
-			t.ID, t.FromAccountID, t.ToAccountID, t.Amount)
+		fmt.Printf("ID: %d, 从账户%d向账户%d转账%s %s", t.ID, t.FromAccountID, t.ToAccountID, t.Amount, t.Currency)
+		if t.ExchangeRate != nil {
+			fmt.Printf("（到账 %s %s，汇率 %s）", t.TargetAmount, t.TargetCurrency, t.ExchangeRate)
+		}
+		fmt.Printf(", 渠道: %s, 状态: %s", t.Channel, t.Status)
+		if t.Memo != "" {
+			fmt.Printf(", 备注: %s", t.Memo)
+		}
+		if t.Reason != "" {
+			fmt.Printf(", 原因: %s", t.Reason)
+		}
+		fmt.Println()
+	}
+
+	// 按发起人查询转账
+	byInitiator, err := FindTransfers(db, TransferQuery{InitiatorID: req.InitiatorID, From: time.Now().AddDate(0, 0, -7)})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\n发起人 %s 最近一周的转账: %d 笔\n", req.InitiatorID, len(byInitiator))
+
+	// 显示每个账户的对账单，并核对账户余额与分录
+	for _, id := range demoAccounts {
+		st, err := AccountStatement(db, id, time.Time{}, time.Time{}, "")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\n账户%d 对账单（%s）:\n", id, st.Currency)
+		for _, l := range st.Lines {
+			fmt.Printf("凭证 %d: %s, 支出 %s, 收入 %s, 余额 %s\n", l.JournalEntryID, l.Description, l.Debit, l.Credit, l.Balance)
+		}
+		fmt.Printf("期末余额: %s\n", st.ClosingBalance)
+	}
+	discrepancies, err := VerifyLedger(db)
+	if err != nil {
+		return err
+	}
+	if len(discrepancies) > 0 {
+		return fmt.Errorf("%d 个账户的余额与分录不一致", len(discrepancies))
+	}
+	fmt.Println("\n账本核对通过：所有账户余额与分录合计一致")
+	return nil
+}
+
+// balanceCents 以分为单位读取余额的 SQL 表达式
+// SQLite 没有定点小数类型，DECIMAL 列实际按浮点数存储，先换算为分并取整，比较和加减才能精确到分。
+const balanceCents = "ROUND(balance * 100)"
+
+// Transfer 执行转账并返回交易记录
+// 转账先以 pending 状态写入交易记录，再在一个事务中扣款、入账、记账并标记为 completed；
+// 事务失败时余额不变，交易记录标记为 failed 并记录原因。
+//
+// 请求带有幂等键时，如果该键已经对应一笔参数相同的转账，直接返回那笔交易记录而不再转账；
+// 账户、金额、备注、发起人或渠道不同时返回 ErrIdempotencyConflict；那笔转账仍是 pending 状态（正在处理，或进程在扣款前退出）时返回 ErrTransferInProgress，
+// 停留在 pending 的记录由 ExpirePendingTransfers 清理。失败的转账同样占用幂等键，重试返回那笔交易记录和 ErrTransferFailed，
+// 不会再次执行，避免超时后客户端重试在余额已经变化时成功；重新转账需要使用新的幂等键。
+//
+// 扣款前在同一个事务中检查账户状态和转出账户的转账限制（AccountPolicy）：冻结的账户不能转出（ErrAccountFrozen），
+// 已销户的账户不能转入或转出（ErrAccountClosed），违反转账限制时的原因为 *PolicyViolation。
+//
+// 失败时返回 *TransferError，原因可以用 errors.Is 判断，例如 ErrInsufficientFunds、ErrAccountNotFound、ErrPolicyViolation。
+func Transfer(db *gorm.DB, req TransferRequest) (*Transaction, error) {
+	transaction, err := transfer(db, req)
+	if err != nil {
+		te := &TransferError{Op: "转账", FromAccountID: req.FromAccountID, ToAccountID: req.ToAccountID, Amount: req.Amount, Err: err}
+		if transaction != nil {
+			te.TransactionID = transaction.ID
+		}
+		return nil, te
+	}
+	return transaction, nil
+}
+
+// transfer 执行转账；失败时如果已经写入交易记录，同时返回标记为 failed 的记录
+func transfer(db *gorm.DB, req TransferRequest) (*Transaction, error) {
+	if err := validateRequest(req); err != nil {
+		return nil, err
+	}
+	if req.IdempotencyKey != "" {
+		if existing, err := replay(db, req); existing != nil || err != nil {
+			return existing, err
+		}
+	}
+
+	// 转出金额以转出账户的币种计；账户不存在时币种为空，随后的转账事务会失败
+	var from Account
+	if err := db.Select("currency").Limit(1).Find(&from, req.FromAccountID).Error; err != nil {
+		return nil, fmt.Errorf("查询转出账户失败: %w", err)
+	}
+	transaction := newPendingTransaction(req, from.Currency, ChannelAPI)
+	if req.IdempotencyKey != "" {
+		transaction.IdempotencyKey = &req.IdempotencyKey
+	}
+	if err := db.Create(&transaction).Error; err != nil {
+		// 同一个键的并发请求中只有一个能写入交易记录（唯一索引），其余请求返回已写入的记录
+		if req.IdempotencyKey != "" {
+			if existing, replayErr := replay(db, req); existing != nil || replayErr != nil {
+				return existing, replayErr
+			}
+		}
+		return nil, fmt.Errorf("记录交易信息失败: %w", err)
+	}
+
+	if err := transferMoneyTx(db, &transaction); err != nil {
+		if markErr := markFailed(db, &transaction, err); markErr != nil {
+			return &transaction, fmt.Errorf("%w（标记交易 %d 失败时出错: %w）", err, transaction.ID, markErr)
+		}
+		transaction.Status = StatusFailed
+		return &transaction, err
+	}
+	return &transaction, nil
+}
+
+// validateRequest 校验转账金额、账户和备注等附加信息
+func validateRequest(req TransferRequest) error {
+	if !req.Amount.IsPositive() {
+		return fmt.Errorf("%w: %s", ErrInvalidAmount, req.Amount)
+	}
+	if req.FromAccountID == req.ToAccountID {
+		return fmt.Errorf("%w: 账户%d", ErrSameAccount, req.FromAccountID)
+	}
+	if req.Channel != "" && !req.Channel.Valid() {
+		return fmt.Errorf("不支持的转账渠道 %q（可选: %s、%s、%s、%s）", req.Channel, ChannelAPI, ChannelBatch, ChannelAdmin, ChannelScheduled)
+	}
+	if n := utf8.RuneCountInString(req.Memo); n > 255 {
+		return fmt.Errorf("备注不能超过 255 个字符（%d 个）", n)
+	}
+	if n := utf8.RuneCountInString(req.InitiatorID); n > 64 {
+		return fmt.Errorf("发起人不能超过 64 个字符（%d 个）", n)
 	}
+	return nil
+}
+
+// newPendingTransaction 按请求创建 pending 状态的交易记录，请求没有指定渠道时使用 channel
+func newPendingTransaction(req TransferRequest, currency money.Currency, channel Channel) Transaction {
+	if req.Channel != "" {
+		channel = req.Channel
+	}
+	return Transaction{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Currency:      currency,
+		Status:        StatusPending,
+		Memo:          req.Memo,
+		InitiatorID:   req.InitiatorID,
+		Channel:       channel,
+	}
+}
+
+// replay 查找幂等键对应的交易记录，参数不同时返回 ErrIdempotencyConflict，仍是 pending 状态时返回 ErrTransferInProgress，
+// 已失败时返回那笔交易记录和 ErrTransferFailed，不存在时返回 nil
+func replay(db *gorm.DB, req TransferRequest) (*Transaction, error) {
+	var existing Transaction
+	result := db.Where("idempotency_key = ?", req.IdempotencyKey).Limit(1).Find(&existing)
+	if result.Error != nil {
+		return nil, fmt.Errorf("查询幂等键失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	// 备注、发起人和渠道同样属于请求参数；Transfer 没有指定渠道时记为 ChannelAPI
+	channel := req.Channel
+	if channel == "" {
+		channel = ChannelAPI
+	}
+	if existing.FromAccountID != req.FromAccountID || existing.ToAccountID != req.ToAccountID || existing.Amount != req.Amount ||
+		existing.Memo != req.Memo || existing.InitiatorID != req.InitiatorID || existing.Channel != channel {
+		return nil, fmt.Errorf("%w: %s（交易 %d）", ErrIdempotencyConflict, req.IdempotencyKey, existing.ID)
+	}
+	// pending 的交易还没有扣款，不能当作成功返回
+	if existing.Status == StatusPending {
+		return nil, fmt.Errorf("%w: %s（交易 %d）", ErrTransferInProgress, req.IdempotencyKey, existing.ID)
+	}
+	if existing.Status == StatusFailed {
+		return &existing, fmt.Errorf("%w: %s（交易 %d）: %s", ErrTransferFailed, req.IdempotencyKey, existing.ID, existing.Reason)
+	}
+	return &existing, nil
+}
+
+// ExpirePendingTransfers 把受理时间早于 olderThan 之前、仍是 pending 状态的转账标记为 failed，返回处理的记录数
+// 转账先在事务外写入 pending 记录，进程在扣款事务提交前退出时记录会一直停留在 pending，
+// 用同一个幂等键重试一直返回 ErrTransferInProgress；标记为 failed 后重试返回 ErrTransferFailed。扣款和标记 completed 在同一个事务中完成，
+// pending 的记录没有移动过资金，标记为 failed 是安全的：仍在执行的扣款事务会因为记录不再是 pending 而回滚。
+// olderThan 应当远大于一次转账的正常耗时，例如由定时任务每隔几分钟调用 ExpirePendingTransfers(db, 10*time.Minute)。
+func ExpirePendingTransfers(db *gorm.DB, olderThan time.Duration) (int64, error) {
+	cutoff := db.NowFunc().Add(-olderThan)
+	result := db.Model(&Transaction{}).
+		Where("status = ? AND created_at < ?", StatusPending, cutoff).
+		Updates(map[string]any{
+			"status": StatusFailed,
+			"reason": fmt.Sprintf("处理超时：超过 %s 仍未完成", olderThan),
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("清理超时的 pending 转账失败: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// transferMoney 执行不带幂等键的转账
+func transferMoney(db *gorm.DB, fromAccountID, toAccountID uint, amount money.Money) error {
+	_, err := Transfer(db, TransferRequest{FromAccountID: fromAccountID, ToAccountID: toAccountID, Amount: amount})
+	return err
+}
+
+// transferMoneyTx 执行转账事务，把 pending 状态的交易记录标记为 completed
+// 事务因死锁或锁等待超时失败时由 database.Transaction 整体重试，每次重试从原始的交易记录开始。
+func transferMoneyTx(db *gorm.DB, transaction *Transaction) error {
+	pending := *transaction
+	return database.Transaction(db, func(tx *gorm.DB) error {
+		attempt := pending
+		// 1. 按 ID 顺序锁定转出和转入账户，读取转出账户的转账限制
+		accounts, err := lockAccounts(tx, attempt.FromAccountID, attempt.ToAccountID)
+		if err != nil {
+			return err
+		}
+		policy, err := EffectivePolicy(tx, accounts[attempt.FromAccountID])
+		if err != nil {
+			return err
+		}
 
-	// 演示余额不足的情况
-	fmt.Println("\n尝试从账户B向账户A转账500元（余额不足）...")
-	err = transferMoney(db, updatedAccountB.ID, updatedAccountA.ID, 500.00)
+		// 2. 扣款、入账并记账
+		if err := applyTransfer(tx, &attempt, accounts, &policy, fmt.Sprintf("转账 账户%d → 账户%d", attempt.FromAccountID, attempt.ToAccountID)); err != nil {
+			return err
+		}
+
+		// 3. 标记为已完成
+		if err := complete(tx, &attempt); err != nil {
+			return err
+		}
+		*transaction = attempt
+		return nil
+	})
+}
+
+// moveFunds 在事务中从转出账户扣款、向转入账户入账，并记一张凭证
+// 转出和转入账户按 ID 升序加行锁（SELECT ... FOR UPDATE），并发转账以相同顺序加锁，不会互相等待形成死锁；
+// 扣款使用带余额条件的原子更新，即使在不支持行锁的 SQLite 上，并发扣款也不会丢失更新或透支。
+//
+// 两个账户币种不同时，按当前生效的汇率换算转入金额（银行家舍入到分），没有汇率时返回 ErrNoExchangeRate。
+// transaction.TargetCurrency 已经设置时（例如冲正）直接使用其中的转入金额和汇率，不再查询汇率。
+// moveFunds 不检查转账限制，用于冲正。
+func moveFunds(tx *gorm.DB, transaction *Transaction, description string) error {
+	// 1. 按 ID 顺序锁定转出和转入账户
+	accounts, err := lockAccounts(tx, transaction.FromAccountID, transaction.ToAccountID)
 	if err != nil {
-		fmt.Printf("转账失败: %v\n", err)
-	} else {
-		fmt.Println("转账成功!")
+		return err
 	}
+	return applyTransfer(tx, transaction, accounts, nil, description)
 }
 
-// transferMoney 执行转账事务
-func transferMoney(db *gorm.DB, fromAccountID, toAccountID uint, amount float64) error {
-	// 开始事务
-	tx := db.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+// applyTransfer 在已经锁定账户的事务中扣款、入账并记账，accounts 为 lockAccounts 返回的账户
+// policy 为转出账户生效的转账限制，违反时返回 *PolicyViolation；为 nil 时只要求转出后余额不为负数。
+func applyTransfer(tx *gorm.DB, transaction *Transaction, accounts map[uint]Account, policy *AccountPolicy, description string) error {
+	fromAccountID, toAccountID, amount := transaction.FromAccountID, transaction.ToAccountID, transaction.Amount
+
+	// 2. 检查账户状态，确定转入金额
+	if err := checkAccountStatus(accounts[fromAccountID], accounts[toAccountID]); err != nil {
+		return err
+	}
+	if err := convert(tx, transaction, accounts[fromAccountID].Currency, accounts[toAccountID].Currency); err != nil {
+		return err
+	}
+
+	// 3. 检查单笔和每日转出上限，确定转出后余额的下限（透支额度、最低余额）
+	var floor money.Money
+	var floorPolicy PolicyName
+	if policy != nil {
+		if err := checkOutgoing(tx, *policy, fromAccountID, amount); err != nil {
+			return err
 		}
-	}()
+		floor, floorPolicy = balanceFloor(*policy)
+	}
 
-	if tx.Error != nil {
-		return tx.Error
+	// 4. 转出后余额不低于下限时扣除转出账户余额，检查和扣款在同一条语句中完成
+	result := tx.Model(&Account{}).
+		Where("id = ? AND "+balanceCents+" - ? >= ?", fromAccountID, amount.Cents(), floor.Cents()).
+		Update("balance", gorm.Expr("("+balanceCents+" - ?) / 100", amount.Cents()))
+	if result.Error != nil {
+		return fmt.Errorf("扣除转出账户余额失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		if floorPolicy == "" {
+			return fmt.Errorf("%w（账户%d 转出 %s）", ErrInsufficientFunds, fromAccountID, amount)
+		}
+		var from Account
+		if err := tx.Select("balance").First(&from, fromAccountID).Error; err != nil {
+			return fmt.Errorf("查询转出账户余额失败: %w", err)
+		}
+		return floorViolation(*policy, floorPolicy, fromAccountID, from.Balance-amount)
 	}
 
-	// 1. 查询转出账户余额
-	var fromAccount Account
-	if err := tx.First(&fromAccount, fromAccountID).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("查询转出账户失败: %v", err)
+	// 5. 增加转入账户余额
+	if err := tx.Model(&Account{}).
+		Where("id = ?", toAccountID).
+		Update("balance", gorm.Expr("("+balanceCents+" + ?) / 100", transaction.TargetAmount.Cents())).Error; err != nil {
+		return fmt.Errorf("增加转入账户余额失败: %w", err)
 	}
 
-	// 2. 检查余额是否足够
-	if fromAccount.Balance < amount {
-		tx.Rollback()
-		return errors.New("余额不足，无法完成转账")
+	// 6. 记账：转出账户借记、转入账户贷记；跨币种时由外部账户按汇率兑换，每个币种分别平衡
+	postings := []Posting{{AccountID: fromAccountID, Amount: -amount, Currency: transaction.Currency}}
+	if transaction.Currency != transaction.TargetCurrency {
+		postings = append(postings,
+			Posting{AccountID: ExternalAccountID, Amount: amount, Currency: transaction.Currency},
+			Posting{AccountID: ExternalAccountID, Amount: -transaction.TargetAmount, Currency: transaction.TargetCurrency},
+		)
 	}
+	postings = append(postings, Posting{AccountID: toAccountID, Amount: transaction.TargetAmount, Currency: transaction.TargetCurrency})
+	return post(tx, &JournalEntry{
+		TransactionID: &transaction.ID,
+		Description:   description,
+		Postings:      postings,
+	})
+}
 
-	// 3. 扣除转出账户余额
-	if err := tx.Model(&fromAccount).Update("balance", fromAccount.Balance-amount).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("扣除转出账户余额失败: %v", err)
+// convert 按转出和转入账户的币种设置交易的币种、转入金额和汇率
+func convert(tx *gorm.DB, transaction *Transaction, from, to money.Currency) error {
+	if transaction.TargetCurrency != "" {
+		if transaction.Currency != from || transaction.TargetCurrency != to {
+			return fmt.Errorf("交易币种 %s/%s 与账户币种 %s/%s 不一致", transaction.Currency, transaction.TargetCurrency, from, to)
+		}
+		return nil
 	}
 
-	// 4. 增加转入账户余额
-	var toAccount Account
-	if err := tx.First(&toAccount, toAccountID).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("查询转入账户失败: %v", err)
+	transaction.Currency, transaction.TargetCurrency = from, to
+	if from == to {
+		transaction.TargetAmount = transaction.Amount
+		return nil
+	}
+	rate, err := LookupRate(tx, from, to, time.Now())
+	if err != nil {
+		return err
+	}
+	target, err := rate.Rate.Convert(transaction.Amount, money.RoundHalfEven)
+	if err != nil {
+		return err
 	}
+	if !target.IsPositive() {
+		return fmt.Errorf("%w: %s %s 按汇率 %s 换算后不足 0.01 %s", ErrInvalidAmount, transaction.Amount, from, rate.Rate, to)
+	}
+	transaction.TargetAmount = target
+	transaction.ExchangeRate = &rate.Rate
+	return nil
+}
 
-	if err := tx.Model(&toAccount).Update("balance", toAccount.Balance+amount).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("增加转入账户余额失败: %v", err)
+// complete 把 pending 状态的交易记录标记为 completed，同时保存扣款时确定的币种、转入金额和汇率
+func complete(tx *gorm.DB, transaction *Transaction) error {
+	now := tx.NowFunc()
+	result := tx.Model(transaction).
+		Where("status = ?", StatusPending).
+		Updates(map[string]any{
+			"status":          StatusCompleted,
+			"completed_at":    now,
+			"currency":        transaction.Currency,
+			"target_amount":   transaction.TargetAmount,
+			"target_currency": transaction.TargetCurrency,
+			"exchange_rate":   transaction.ExchangeRate,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("更新交易状态失败: %w", result.Error)
 	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("交易 %d 不是 %s 状态", transaction.ID, StatusPending)
+	}
+	transaction.Status = StatusCompleted
+	transaction.CompletedAt = &now
+	return nil
+}
 
-	// 5. 记录交易信息
-	transaction := Transaction{
-		FromAccountID: fromAccountID,
-		ToAccountID:   toAccountID,
-		Amount:        amount,
+// markFailed 把转账标记为失败；幂等键仍然指向这笔交易，重试返回失败结果
+func markFailed(db *gorm.DB, transaction *Transaction, cause error) error {
+	return db.Model(transaction).Updates(map[string]any{
+		"status": StatusFailed,
+		"reason": truncate(cause.Error(), 255),
+	}).Error
+}
+
+// truncate 按字符截断字符串，使其不超过 n 个字符
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
 	}
+	return s
+}
+
+// ReverseTransfer 冲正一笔已完成的转账
+// 在一个事务中把原金额从转入账户转回转出账户，写入一笔 ReversalOfID 指向原交易的冲正交易，并把原交易标记为 reversed。
+// 原交易不是 completed 状态（例如已经冲正过）时返回 ErrNotReversible；转入账户余额不足时冲正失败，余额不变。
+// 冲正同样检查账户状态：原交易的转入账户冻结或任一账户已销户时不能冲正。
+// 冲正交易的渠道为 ChannelAdmin。
+// 失败时返回 *TransferError，TransactionID 为原交易。
+func ReverseTransfer(db *gorm.DB, id uint, reason string) (*Transaction, error) {
+	var original, reversal Transaction
+	err := database.Transaction(db, func(tx *gorm.DB) error {
+		original = Transaction{}
+		if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).First(&original, id).Error; err != nil {
+			return fmt.Errorf("查询交易 %d 失败: %w", id, err)
+		}
+		if original.Status != StatusCompleted {
+			return fmt.Errorf("%w: 交易 %d 的状态为 %s", ErrNotReversible, id, original.Status)
+		}
+
+		// 原交易标记为已冲正；条件更新使并发的冲正请求只有一个成功
+		result := tx.Model(&original).
+			Where("status = ?", StatusCompleted).
+			Updates(map[string]any{"status": StatusReversed, "reason": truncate(reason, 255)})
+		if result.Error != nil {
+			return fmt.Errorf("更新交易状态失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: 交易 %d 已被冲正", ErrNotReversible, id)
+		}
 
-	if err := tx.Create(&transaction).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("记录交易信息失败: %v", err)
+		// 冲正按原交易的金额原路退回，跨币种时不按当前汇率重新换算
+		reversal = Transaction{
+			FromAccountID:  original.ToAccountID,
+			ToAccountID:    original.FromAccountID,
+			Amount:         original.TargetAmount,
+			Currency:       original.TargetCurrency,
+			TargetAmount:   original.Amount,
+			TargetCurrency: original.Currency,
+			ExchangeRate:   original.ExchangeRate,
+			Status:         StatusPending,
+			Reason:         truncate(reason, 255),
+			Channel:        ChannelAdmin,
+			ReversalOfID:   &original.ID,
+		}
+		if err := tx.Create(&reversal).Error; err != nil {
+			return fmt.Errorf("记录冲正交易失败: %w", err)
+		}
+		if err := moveFunds(tx, &reversal, fmt.Sprintf("冲正交易 %d", original.ID)); err != nil {
+			return err
+		}
+		return complete(tx, &reversal)
+	})
+	if err != nil {
+		// 原交易的转入账户是冲正的转出账户
+		return nil, &TransferError{
+			Op:            "冲正",
+			TransactionID: id,
+			FromAccountID: original.ToAccountID,
+			ToAccountID:   original.FromAccountID,
+			Amount:        original.TargetAmount,
+			Err:           err,
+		}
 	}
+	return &reversal, nil
+}
 
-	// 提交事务
-	return tx.Commit().Error
+// lockAccounts 按 ID 升序逐个锁定账户并返回锁定时读取的账户，账户不存在时返回错误
+func lockAccounts(tx *gorm.DB, ids ...uint) (map[uint]Account, error) {
+	sorted := slices.Compact(slices.Sorted(slices.Values(ids)))
+	accounts := make(map[uint]Account, len(sorted))
+	for _, id := range sorted {
+		var account Account
+		if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).First(&account, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("%w: 账户%d: %w", ErrAccountNotFound, id, err)
+			}
+			return nil, fmt.Errorf("锁定账户 %d 失败: %w", id, err)
+		}
+		accounts[id] = account
+	}
+	return accounts, nil
 }
@@ -0,0 +1,623 @@
+package gormSqlTwo
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm/database"
+	"gorm/internal/testdb"
+	"gorm/money"
+
+	"gorm.io/gorm"
+)
+
+// setupAccounts 迁移表结构并创建两个测试账户
+func setupAccounts(t *testing.T, balanceA, balanceB money.Money) (*gorm.DB, Account, Account) {
+	t.Helper()
+	db := testdb.Open(t)
+	if err := (&Scenario{}).Setup(context.Background(), db); err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	a, err := OpenAccount(db, money.CNY, balanceA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := OpenAccount(db, money.CNY, balanceB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, *a, *b
+}
+
+func balanceOf(t *testing.T, db *gorm.DB, id uint) money.Money {
+	t.Helper()
+	var acc Account
+	if err := db.First(&acc, id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return acc.Balance
+}
+
+func TestTransferMoney(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(500), money.FromUnits(300))
+
+	if err := transferMoney(db, a.ID, b.ID, money.FromUnits(100)); err != nil {
+		t.Fatalf("transferMoney: %v", err)
+	}
+
+	if got := balanceOf(t, db, a.ID); got != money.FromUnits(400) {
+		t.Errorf("账户A余额 = %s, 期望 400.00", got)
+	}
+	if got := balanceOf(t, db, b.ID); got != money.FromUnits(400) {
+		t.Errorf("账户B余额 = %s, 期望 400.00", got)
+	}
+
+	var txs []Transaction
+	if err := db.Find(&txs).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(txs) != 1 {
+		t.Fatalf("交易记录数 = %d, 期望 1", len(txs))
+	}
+	if tx := txs[0]; tx.FromAccountID != a.ID || tx.ToAccountID != b.ID || tx.Amount != money.FromUnits(100) ||
+		tx.Status != StatusCompleted || tx.CreatedAt.IsZero() || tx.CompletedAt == nil {
+		t.Errorf("交易记录 = %+v", tx)
+	}
+}
+
+func TestTransferMoneyInsufficientFundsRollsBack(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(500), money.FromUnits(300))
+
+	err := transferMoney(db, b.ID, a.ID, money.FromUnits(500))
+	if !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("err = %v, 期望 ErrInsufficientFunds", err)
+	}
+	var te *TransferError
+	if !errors.As(err, &te) || te.FromAccountID != b.ID || te.ToAccountID != a.ID || te.Amount != money.FromUnits(500) || te.TransactionID == 0 {
+		t.Errorf("TransferError = %+v", te)
+	}
+
+	if got := balanceOf(t, db, a.ID); got != money.FromUnits(500) {
+		t.Errorf("账户A余额 = %s, 期望 500.00", got)
+	}
+	if got := balanceOf(t, db, b.ID); got != money.FromUnits(300) {
+		t.Errorf("账户B余额 = %s, 期望 300.00", got)
+	}
+	// 失败的转账保留交易记录，状态为 failed 并记录原因
+	var txs []Transaction
+	if err := db.Find(&txs).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(txs) != 1 || txs[0].Status != StatusFailed || !strings.HasPrefix(txs[0].Reason, ErrInsufficientFunds.Error()) || txs[0].CompletedAt != nil {
+		t.Errorf("交易记录 = %+v, 期望一笔 failed 记录", txs)
+	}
+}
+
+func TestTransferMoneyUnknownToAccountRollsBack(t *testing.T) {
+	db, a, _ := setupAccounts(t, money.FromUnits(500), money.FromUnits(300))
+
+	err := transferMoney(db, a.ID, 9999, money.FromUnits(100))
+	if !errors.Is(err, ErrAccountNotFound) || !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("err = %v, 期望 ErrAccountNotFound 和 gorm.ErrRecordNotFound", err)
+	}
+	if got := balanceOf(t, db, a.ID); got != money.FromUnits(500) {
+		t.Errorf("账户A余额 = %s, 期望扣款被回滚", got)
+	}
+}
+
+func TestScenario(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	s := &Scenario{Amount: money.FromUnits(100)}
+
+	if err := s.Setup(ctx, db); err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	if err := s.Run(ctx, db); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+}
+
+func TestTransferMoneyKeepsCents(t *testing.T) {
+	db, a, b := setupAccounts(t, money.MustParse("0.30"), 0)
+
+	// 0.1 元转账三次：float64 下 0.30 - 0.1 - 0.1 - 0.1 不等于 0，余额比较会出错
+	for i := 0; i < 3; i++ {
+		if err := transferMoney(db, a.ID, b.ID, money.MustParse("0.10")); err != nil {
+			t.Fatalf("第 %d 次转账: %v", i+1, err)
+		}
+	}
+	if got := balanceOf(t, db, a.ID); got != 0 {
+		t.Errorf("账户A余额 = %s, 期望 0.00", got)
+	}
+	if got := balanceOf(t, db, b.ID); got != money.MustParse("0.30") {
+		t.Errorf("账户B余额 = %s, 期望 0.30", got)
+	}
+	if err := transferMoney(db, a.ID, b.ID, money.FromCents(1)); err == nil {
+		t.Error("余额为 0 时转账 0.01 应当失败")
+	}
+}
+
+// setupFileAccounts 在文件 SQLite 数据库中创建 n 个余额为 balance 的账户，用于并发测试
+func setupFileAccounts(t *testing.T, n int, balance money.Money) (*gorm.DB, []uint) {
+	t.Helper()
+	return createAccounts(t, testdb.OpenFile(t), n, balance)
+}
+
+// createAccounts 迁移表结构并创建 n 个余额为 balance 的账户
+func createAccounts(t *testing.T, db *gorm.DB, n int, balance money.Money) (*gorm.DB, []uint) {
+	t.Helper()
+	if err := (&Scenario{}).Setup(context.Background(), db); err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	ids := make([]uint, n)
+	for i := range ids {
+		acc, err := OpenAccount(db, money.CNY, balance)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[i] = acc.ID
+	}
+	return db, ids
+}
+
+func TestTransferMoneyConcurrentDrain(t *testing.T) {
+	db, ids := setupFileAccounts(t, 2, money.FromUnits(100))
+
+	// 10 个并发转账各转出 20 元，余额只够其中 5 个，不能多扣也不能丢失扣款
+	// SQLite 以 BEGIN IMMEDIATE 串行执行写事务；在 MySQL 上由行锁和带余额条件的更新保证同样的结果。
+	const workers = 10
+	var wg sync.WaitGroup
+	var succeeded atomic.Int32
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := transferMoney(db, ids[0], ids[1], money.FromUnits(20)); err == nil {
+				succeeded.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := succeeded.Load(); got != 5 {
+		t.Errorf("成功的转账 = %d, 期望 5", got)
+	}
+	if got := balanceOf(t, db, ids[0]); got != 0 {
+		t.Errorf("转出账户余额 = %s, 期望 0.00", got)
+	}
+	if got := balanceOf(t, db, ids[1]); got != money.FromUnits(200) {
+		t.Errorf("转入账户余额 = %s, 期望 200.00", got)
+	}
+}
+
+func TestTransferMoneyConcurrentConservesTotal(t *testing.T) {
+	// BEGIN IMMEDIATE 使写事务串行执行，这里验证的是并发请求交错时余额、交易记录和分录的一致性；
+	// 事务真正并发、在带余额条件的扣款上冲突的情况见 TestTransferMoneyConcurrentDeferred。
+	db, ids := setupFileAccounts(t, 5, money.FromUnits(1000))
+	checkConcurrentTransfers(t, db, ids, money.FromUnits(1000), false)
+}
+
+func TestTransferMoneyConcurrentDeferred(t *testing.T) {
+	// BEGIN DEFERRED 的事务并发读取余额后再扣款，冲突的一方收到 SQLITE_BUSY，由 database.Transaction 回滚重试；
+	// 重试次数用完的转账以失败结束，但不能多扣或丢失扣款。
+	db, ids := createAccounts(t, testdb.OpenFileDeferred(t), 5, money.FromUnits(1000))
+	checkConcurrentTransfers(t, db, ids, money.FromUnits(1000), true)
+}
+
+// checkConcurrentTransfers 多个 goroutine 在账户之间双向随机转账（金额可能超过余额），
+// 然后检查总金额守恒、没有账户透支、每个账户的余额与交易记录和分录一致。
+// allowBusy 为 true 时允许转账因重试次数用完的并发冲突而失败。
+func checkConcurrentTransfers(t *testing.T, db *gorm.DB, ids []uint, initial money.Money, allowBusy bool) {
+	t.Helper()
+	const (
+		workers   = 8
+		transfers = 40
+	)
+	accounts := len(ids)
+
+	var wg sync.WaitGroup
+	var busy atomic.Int32
+	errs := make(chan error, workers*transfers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(seed))
+			for i := 0; i < transfers; i++ {
+				from := ids[r.Intn(accounts)]
+				to := ids[r.Intn(accounts)]
+				if from == to {
+					continue
+				}
+				amount := money.FromCents(1 + r.Int63n(50000))
+				err := transferMoney(db, from, to, amount)
+				switch {
+				case err == nil, errors.Is(err, ErrInsufficientFunds):
+				case allowBusy && database.IsRetryable(err):
+					busy.Add(1)
+				default:
+					errs <- err
+				}
+			}
+		}(int64(w))
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("转账失败: %v", err)
+	}
+	if n := busy.Load(); n > 0 {
+		t.Logf("%d 笔转账重试后仍然冲突", n)
+	}
+
+	// 总金额守恒，没有账户透支，每个账户的余额与交易记录一致
+	var list []Account
+	if err := db.Find(&list).Error; err != nil {
+		t.Fatal(err)
+	}
+	var txs []Transaction
+	if err := db.Where("status = ?", StatusCompleted).Find(&txs).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(txs) == 0 {
+		t.Fatal("没有成功的转账")
+	}
+	expected := make(map[uint]money.Money)
+	for _, id := range ids {
+		expected[id] = initial
+	}
+	for _, tx := range txs {
+		expected[tx.FromAccountID] -= tx.Amount
+		expected[tx.ToAccountID] += tx.Amount
+	}
+
+	var total money.Money
+	for _, acc := range list {
+		total += acc.Balance
+		if acc.Balance < 0 {
+			t.Errorf("账户 %d 透支: %s", acc.ID, acc.Balance)
+		}
+		if acc.Balance != expected[acc.ID] {
+			t.Errorf("账户 %d 余额 = %s, 按交易记录应为 %s", acc.ID, acc.Balance, expected[acc.ID])
+		}
+	}
+	if want := initial * money.Money(accounts); total != want {
+		t.Errorf("总金额 = %s, 期望 %s", total, want)
+	}
+	if discrepancies, err := VerifyLedger(db); err != nil || len(discrepancies) > 0 {
+		t.Errorf("VerifyLedger = %+v, %v", discrepancies, err)
+	}
+}
+
+func TestTransferIdempotencyKey(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(500), money.FromUnits(300))
+	req := TransferRequest{FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.FromUnits(100), IdempotencyKey: "order-1"}
+
+	first, err := Transfer(db, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 重试返回原交易，余额只变动一次
+	retried, err := Transfer(db, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retried.ID != first.ID {
+		t.Errorf("重试返回交易 %d, 期望原交易 %d", retried.ID, first.ID)
+	}
+	if got := balanceOf(t, db, a.ID); got != money.FromUnits(400) {
+		t.Errorf("账户A余额 = %s, 期望 400.00", got)
+	}
+
+	// 同一个键、不同参数被拒绝
+	conflict := req
+	conflict.Amount = money.FromUnits(200)
+	if _, err := Transfer(db, conflict); !errors.Is(err, ErrIdempotencyConflict) {
+		t.Errorf("不同金额: err = %v, 期望 ErrIdempotencyConflict", err)
+	}
+	conflict = req
+	conflict.ToAccountID = a.ID
+	conflict.FromAccountID = b.ID
+	if _, err := Transfer(db, conflict); !errors.Is(err, ErrIdempotencyConflict) {
+		t.Errorf("不同账户: err = %v, 期望 ErrIdempotencyConflict", err)
+	}
+
+	// 备注、发起人或渠道不同同样被拒绝
+	for name, change := range map[string]func(*TransferRequest){
+		"备注":  func(r *TransferRequest) { r.Memo = "另一笔" },
+		"发起人": func(r *TransferRequest) { r.InitiatorID = "mallory" },
+		"渠道":  func(r *TransferRequest) { r.Channel = ChannelAdmin },
+	} {
+		conflict = req
+		change(&conflict)
+		if _, err := Transfer(db, conflict); !errors.Is(err, ErrIdempotencyConflict) {
+			t.Errorf("不同%s: err = %v, 期望 ErrIdempotencyConflict", name, err)
+		}
+	}
+	// 显式指定默认渠道与不指定等价
+	same := req
+	same.Channel = ChannelAPI
+	if replayed, err := Transfer(db, same); err != nil || replayed.ID != first.ID {
+		t.Errorf("指定默认渠道重试: %v, %v, 期望返回原交易", replayed, err)
+	}
+
+	// 不同的键是新的转账
+	req.IdempotencyKey = "order-2"
+	if _, err := Transfer(db, req); err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	db.Model(&Transaction{}).Count(&count)
+	if count != 2 {
+		t.Errorf("交易记录数 = %d, 期望 2", count)
+	}
+	if got := balanceOf(t, db, a.ID); got != money.FromUnits(300) {
+		t.Errorf("账户A余额 = %s, 期望 300.00", got)
+	}
+}
+
+func TestTransferIdempotencyKeyAfterFailure(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(50), 0)
+	req := TransferRequest{FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.FromUnits(100), IdempotencyKey: "retry-me"}
+
+	_, err := Transfer(db, req)
+	var first *TransferError
+	if !errors.As(err, &first) || !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("余额不足: err = %v", err)
+	}
+
+	// 幂等键仍然指向失败的交易：余额补足后重试也返回原来的失败结果，不会再次执行
+	db.Model(&Account{}).Where("id = ?", a.ID).Update("balance", money.FromUnits(100))
+	_, err = Transfer(db, req)
+	var retried *TransferError
+	if !errors.As(err, &retried) || !errors.Is(err, ErrTransferFailed) || retried.TransactionID != first.TransactionID {
+		t.Fatalf("重试: err = %v, 期望交易 %d 的 ErrTransferFailed", err, first.TransactionID)
+	}
+	if got := balanceOf(t, db, a.ID); got != money.FromUnits(100) {
+		t.Errorf("账户A余额 = %s, 期望 100.00", got)
+	}
+
+	// 重新转账使用新的幂等键
+	req.IdempotencyKey = "retry-me-2"
+	if _, err := Transfer(db, req); err != nil {
+		t.Fatalf("新的幂等键: %v", err)
+	}
+}
+
+func TestTransferIdempotencyKeyConcurrent(t *testing.T) {
+	db, ids := setupFileAccounts(t, 2, money.FromUnits(1000))
+	req := TransferRequest{FromAccountID: ids[0], ToAccountID: ids[1], Amount: money.FromUnits(10), IdempotencyKey: "concurrent"}
+
+	const workers = 8
+	results := make([]uint, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tx, err := Transfer(db, req)
+			if err != nil {
+				// 第一个请求还没有完成时，其余请求得到 ErrTransferInProgress
+				if !errors.Is(err, ErrTransferInProgress) {
+					t.Errorf("Transfer: %v", err)
+				}
+				return
+			}
+			results[i] = tx.ID
+		}(i)
+	}
+	wg.Wait()
+
+	var first uint
+	for _, id := range results {
+		if id == 0 {
+			continue
+		}
+		if first == 0 {
+			first = id
+		}
+		if id != first {
+			t.Fatalf("并发重试返回了不同的交易: %v", results)
+		}
+	}
+	if first == 0 {
+		t.Fatal("没有请求成功")
+	}
+	if got := balanceOf(t, db, ids[0]); got != money.FromUnits(990) {
+		t.Errorf("转出账户余额 = %s, 期望只扣款一次 990.00", got)
+	}
+}
+
+func TestTransferPendingIdempotencyKey(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(500), 0)
+	req := TransferRequest{FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.FromUnits(100), IdempotencyKey: "crashed"}
+
+	// 模拟进程写入 pending 记录后、扣款前退出
+	stale := newPendingTransaction(req, money.CNY, ChannelAPI)
+	stale.IdempotencyKey = &req.IdempotencyKey
+	stale.CreatedAt = time.Now().Add(-time.Hour).UTC()
+	if err := db.Create(&stale).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Transfer(db, req); !errors.Is(err, ErrTransferInProgress) {
+		t.Fatalf("pending 的幂等键: err = %v, 期望 ErrTransferInProgress", err)
+	}
+	if got := balanceOf(t, db, a.ID); got != money.FromUnits(500) {
+		t.Errorf("账户A余额 = %s, 期望 500.00", got)
+	}
+
+	// 最近受理的 pending 记录不受影响，超时的记录标记为 failed
+	recent := newPendingTransaction(TransferRequest{FromAccountID: a.ID, ToAccountID: b.ID, Amount: 1}, money.CNY, ChannelAPI)
+	if err := db.Create(&recent).Error; err != nil {
+		t.Fatal(err)
+	}
+	if n, err := ExpirePendingTransfers(db, 10*time.Minute); err != nil || n != 1 {
+		t.Fatalf("ExpirePendingTransfers = %d, %v, 期望 1", n, err)
+	}
+	var expired Transaction
+	if err := db.First(&expired, stale.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if expired.Status != StatusFailed || expired.IdempotencyKey == nil || expired.Reason == "" {
+		t.Errorf("超时的交易记录 = %+v", expired)
+	}
+
+	// 用同一个键重试返回超时的失败结果，不会扣款；重新转账使用新的幂等键
+	if _, err := Transfer(db, req); !errors.Is(err, ErrTransferFailed) {
+		t.Fatalf("清理后重试: err = %v, 期望 ErrTransferFailed", err)
+	}
+	if got := balanceOf(t, db, a.ID); got != money.FromUnits(500) {
+		t.Errorf("账户A余额 = %s, 期望 500.00", got)
+	}
+	req.IdempotencyKey = "crashed-retry"
+	if retried, err := Transfer(db, req); err != nil || retried.Status != StatusCompleted {
+		t.Fatalf("新的幂等键: %+v, %v", retried, err)
+	}
+}
+
+func TestReverseTransfer(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(500), money.FromUnits(300))
+	original, err := Transfer(db, TransferRequest{FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.FromUnits(100)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reversal, err := ReverseTransfer(db, original.ID, "客户申请退款")
+	if err != nil {
+		t.Fatalf("ReverseTransfer: %v", err)
+	}
+	if reversal.FromAccountID != b.ID || reversal.ToAccountID != a.ID || reversal.Amount != original.Amount ||
+		reversal.Status != StatusCompleted || reversal.ReversalOfID == nil || *reversal.ReversalOfID != original.ID {
+		t.Errorf("冲正交易 = %+v", reversal)
+	}
+	if got := balanceOf(t, db, a.ID); got != money.FromUnits(500) {
+		t.Errorf("账户A余额 = %s, 期望 500.00", got)
+	}
+	if got := balanceOf(t, db, b.ID); got != money.FromUnits(300) {
+		t.Errorf("账户B余额 = %s, 期望 300.00", got)
+	}
+
+	var reloaded Transaction
+	if err := db.First(&reloaded, original.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.Status != StatusReversed || reloaded.Reason != "客户申请退款" {
+		t.Errorf("原交易 = %+v, 期望 reversed", reloaded)
+	}
+
+	// 不能重复冲正，也不能冲正失败的转账
+	if _, err := ReverseTransfer(db, original.ID, "重复"); !errors.Is(err, ErrNotReversible) {
+		t.Errorf("重复冲正: err = %v, 期望 ErrNotReversible", err)
+	}
+	if err := transferMoney(db, a.ID, b.ID, money.FromUnits(1000)); err == nil {
+		t.Fatal("余额不足的转账应当失败")
+	}
+	var failed Transaction
+	if err := db.Where("status = ?", StatusFailed).First(&failed).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReverseTransfer(db, failed.ID, "冲正失败的转账"); !errors.Is(err, ErrNotReversible) {
+		t.Errorf("冲正失败的转账: err = %v, 期望 ErrNotReversible", err)
+	}
+
+	if discrepancies, err := VerifyLedger(db); err != nil || len(discrepancies) != 0 {
+		t.Errorf("VerifyLedger = %+v, %v", discrepancies, err)
+	}
+}
+
+func TestReverseTransferInsufficientFunds(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(500), 0)
+	original, err := Transfer(db, TransferRequest{FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.FromUnits(100)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 转入账户已经把钱转走，冲正失败，原交易保持 completed
+	if err := transferMoney(db, b.ID, a.ID, money.FromUnits(60)); err != nil {
+		t.Fatal(err)
+	}
+	_, err = ReverseTransfer(db, original.ID, "退款")
+	if !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("err = %v, 期望 ErrInsufficientFunds", err)
+	}
+	var te *TransferError
+	if !errors.As(err, &te) || te.TransactionID != original.ID || te.FromAccountID != b.ID || te.ToAccountID != a.ID {
+		t.Errorf("TransferError = %+v", te)
+	}
+
+	var reloaded Transaction
+	if err := db.First(&reloaded, original.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.Status != StatusCompleted {
+		t.Errorf("原交易状态 = %s, 期望 completed", reloaded.Status)
+	}
+	if got := balanceOf(t, db, b.ID); got != money.FromUnits(40) {
+		t.Errorf("账户B余额 = %s, 期望 40.00", got)
+	}
+}
+
+func TestReverseTransferConcurrent(t *testing.T) {
+	db, ids := setupFileAccounts(t, 2, money.FromUnits(1000))
+	original, err := Transfer(db, TransferRequest{FromAccountID: ids[0], ToAccountID: ids[1], Amount: money.FromUnits(100)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const workers = 8
+	var wg sync.WaitGroup
+	var succeeded atomic.Int32
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := ReverseTransfer(db, original.ID, "并发冲正"); err == nil {
+				succeeded.Add(1)
+			} else if !errors.Is(err, ErrNotReversible) {
+				t.Errorf("ReverseTransfer: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := succeeded.Load(); got != 1 {
+		t.Errorf("成功的冲正 = %d, 期望 1", got)
+	}
+	if got := balanceOf(t, db, ids[0]); got != money.FromUnits(1000) {
+		t.Errorf("转出账户余额 = %s, 期望 1000.00", got)
+	}
+}
+
+func TestTransferValidation(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(500), 0)
+
+	tests := []struct {
+		name string
+		req  TransferRequest
+		want error
+	}{
+		{"金额为零", TransferRequest{FromAccountID: a.ID, ToAccountID: b.ID}, ErrInvalidAmount},
+		{"金额为负", TransferRequest{FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.FromUnits(-1)}, ErrInvalidAmount},
+		{"同一账户", TransferRequest{FromAccountID: a.ID, ToAccountID: a.ID, Amount: money.FromUnits(1)}, ErrSameAccount},
+		{"转出账户不存在", TransferRequest{FromAccountID: 9999, ToAccountID: b.ID, Amount: money.FromUnits(1)}, ErrAccountNotFound},
+	}
+	for _, tt := range tests {
+		_, err := Transfer(db, tt.req)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, 期望 %v", tt.name, err, tt.want)
+		}
+		var te *TransferError
+		if !errors.As(err, &te) || te.FromAccountID != tt.req.FromAccountID || te.ToAccountID != tt.req.ToAccountID {
+			t.Errorf("%s: TransferError = %+v", tt.name, te)
+		}
+	}
+	if got := balanceOf(t, db, a.ID); got != money.FromUnits(500) {
+		t.Errorf("账户A余额 = %s, 期望 500.00", got)
+	}
+}
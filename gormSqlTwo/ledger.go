@@ -0,0 +1,141 @@
+package gormSqlTwo
+
+import (
+	"fmt"
+	"time"
+
+	"gorm/money"
+
+	"gorm.io/gorm"
+)
+
+// 复式记账
+// 每笔资金变动记一张凭证（JournalEntry），凭证下至少有两条分录（Posting），每个币种的分录金额之和为零。
+// 账户余额等于该账户全部分录的合计，accounts.balance 只是便于查询和加锁的冗余值，可以用 VerifyLedger 核对。
+
+// ExternalAccountID 外部账户，记录开户存入、货币兑换等与系统外部的资金往来，不在 accounts 表中
+const ExternalAccountID uint = 0
+
+// JournalEntry 记账凭证
+type JournalEntry struct {
+	ID uint `gorm:"primaryKey;autoIncrement"`
+	// TransactionID 对应的转账记录，开户等不对应转账的凭证为 NULL
+	TransactionID *uint
+	Description   string `gorm:"type:varchar(200)"`
+	CreatedAt     time.Time
+	Postings      []Posting
+}
+
+// Posting 分录，金额为正表示账户余额增加，为负表示减少
+type Posting struct {
+	ID             uint `gorm:"primaryKey;autoIncrement"`
+	JournalEntryID uint
+	AccountID      uint
+	Amount         money.Money    `gorm:"type:decimal(12,2)"`
+	Currency       money.Currency `gorm:"type:char(3)"`
+}
+
+// Discrepancy 账户余额与分录合计不一致
+type Discrepancy struct {
+	AccountID  uint           `json:"account_id" label:"账户"`
+	Currency   money.Currency `json:"currency" label:"币种"`
+	Balance    money.Money    `json:"balance" label:"账户余额"`
+	Postings   money.Money    `json:"postings" label:"分录合计"`
+	Difference money.Money    `json:"difference" label:"差额"`
+}
+
+// post 写入凭证及其分录，分录少于两条或某个币种的金额之和不为零时返回 ErrUnbalancedEntry
+func post(tx *gorm.DB, entry *JournalEntry) error {
+	if len(entry.Postings) < 2 {
+		return fmt.Errorf("%w: %s（%d 条分录）", ErrUnbalancedEntry, entry.Description, len(entry.Postings))
+	}
+	sums := make(map[money.Currency]money.Money)
+	for _, p := range entry.Postings {
+		if !p.Currency.Valid() {
+			return fmt.Errorf("分录的币种无效: %q", p.Currency)
+		}
+		sums[p.Currency] += p.Amount
+	}
+	for currency, sum := range sums {
+		if sum != 0 {
+			return fmt.Errorf("%w: %s（%s 合计 %s）", ErrUnbalancedEntry, entry.Description, currency, sum)
+		}
+	}
+	if err := tx.Create(entry).Error; err != nil {
+		return fmt.Errorf("记账失败: %w", err)
+	}
+	return nil
+}
+
+// OpenAccount 开户：创建币种为 currency、余额为 initial 的普通账户，并记一张从外部账户存入的凭证
+func OpenAccount(db *gorm.DB, currency money.Currency, initial money.Money) (*Account, error) {
+	if !currency.Valid() {
+		return nil, fmt.Errorf("不支持的货币 %q", currency)
+	}
+	if initial < 0 {
+		return nil, fmt.Errorf("%w: 开户金额不能为负数 %s", ErrInvalidAmount, initial)
+	}
+	account := Account{Balance: initial, Currency: currency, Type: AccountTypeStandard, Status: AccountActive}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&account).Error; err != nil {
+			return fmt.Errorf("创建账户失败: %w", err)
+		}
+		if initial == 0 {
+			return nil
+		}
+		return post(tx, &JournalEntry{
+			Description: fmt.Sprintf("开户存入 账户%d", account.ID),
+			Postings: []Posting{
+				{AccountID: ExternalAccountID, Amount: -initial, Currency: currency},
+				{AccountID: account.ID, Amount: initial, Currency: currency},
+			},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// AccountPostings 返回账户的全部分录，按写入顺序排列
+func AccountPostings(db *gorm.DB, accountID uint) ([]Posting, error) {
+	var postings []Posting
+	if err := db.Where("account_id = ?", accountID).Order("id").Find(&postings).Error; err != nil {
+		return nil, fmt.Errorf("查询账户 %d 的分录失败: %w", accountID, err)
+	}
+	return postings, nil
+}
+
+// VerifyLedger 核对每个账户的余额与分录合计，返回不一致的账户；同时检查每张凭证每个币种借贷平衡
+func VerifyLedger(db *gorm.DB) ([]Discrepancy, error) {
+	// SQLite 的 DECIMAL 按浮点数存储，按分取整后再比较合计
+	var unbalanced []uint
+	if err := db.Model(&Posting{}).
+		Group("journal_entry_id, currency").
+		Having("ROUND(SUM(amount) * 100) <> 0").
+		Pluck("journal_entry_id", &unbalanced).Error; err != nil {
+		return nil, fmt.Errorf("核对凭证失败: %w", err)
+	}
+	if len(unbalanced) > 0 {
+		return nil, fmt.Errorf("%w: 凭证 %v", ErrUnbalancedEntry, unbalanced)
+	}
+
+	var rows []Discrepancy
+	if err := db.Table("accounts AS a").
+		Select("a.id AS account_id, a.currency AS currency, a.balance AS balance, COALESCE(SUM(p.amount), 0) AS postings").
+		Joins("LEFT JOIN postings AS p ON p.account_id = a.id").
+		Group("a.id, a.currency, a.balance").
+		Order("a.id").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("核对账户余额失败: %w", err)
+	}
+
+	var discrepancies []Discrepancy
+	for _, r := range rows {
+		if r.Balance != r.Postings {
+			r.Difference = r.Balance - r.Postings
+			discrepancies = append(discrepancies, r)
+		}
+	}
+	return discrepancies, nil
+}
@@ -0,0 +1,142 @@
+package gormSqlTwo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gorm/internal/testdb"
+	"gorm/migrate"
+	"gorm/money"
+)
+
+func TestTransferPostsBalancedEntry(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(500), money.FromUnits(300))
+
+	tx, err := Transfer(db, TransferRequest{FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.MustParse("120.50")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var entry JournalEntry
+	if err := db.Preload("Postings").Where("transaction_id = ?", tx.ID).First(&entry).Error; err != nil {
+		t.Fatalf("查询转账凭证: %v", err)
+	}
+	if len(entry.Postings) != 2 {
+		t.Fatalf("分录数 = %d, 期望 2", len(entry.Postings))
+	}
+	got := map[uint]money.Money{}
+	for _, p := range entry.Postings {
+		got[p.AccountID] = p.Amount
+	}
+	if got[a.ID] != money.MustParse("-120.50") || got[b.ID] != money.MustParse("120.50") {
+		t.Errorf("分录 = %v", got)
+	}
+
+	// 失败的转账不记账
+	var before int64
+	db.Model(&JournalEntry{}).Count(&before)
+	if err := transferMoney(db, a.ID, b.ID, money.FromUnits(1000)); err == nil {
+		t.Fatal("余额不足的转账应当失败")
+	}
+	var after int64
+	db.Model(&JournalEntry{}).Count(&after)
+	if after != before {
+		t.Errorf("失败的转账写入了 %d 张凭证", after-before)
+	}
+
+	if discrepancies, err := VerifyLedger(db); err != nil || len(discrepancies) != 0 {
+		t.Errorf("VerifyLedger = %+v, %v", discrepancies, err)
+	}
+}
+
+func TestVerifyLedgerReportsDiscrepancy(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(500), money.FromUnits(300))
+
+	// 绕过记账直接修改余额
+	if err := db.Model(&Account{}).Where("id = ?", b.ID).Update("balance", money.MustParse("310.05")).Error; err != nil {
+		t.Fatal(err)
+	}
+	discrepancies, err := VerifyLedger(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Discrepancy{
+		AccountID:  b.ID,
+		Currency:   money.CNY,
+		Balance:    money.MustParse("310.05"),
+		Postings:   money.FromUnits(300),
+		Difference: money.MustParse("10.05"),
+	}
+	if len(discrepancies) != 1 || discrepancies[0] != want {
+		t.Errorf("VerifyLedger = %+v, 期望 [%+v]（账户 %d 一致）", discrepancies, want, a.ID)
+	}
+}
+
+func TestPostRejectsUnbalancedEntry(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(500), 0)
+
+	tests := map[string][]Posting{
+		"金额不平": {
+			{AccountID: a.ID, Amount: money.FromUnits(-10), Currency: money.CNY},
+			{AccountID: b.ID, Amount: money.FromUnits(9), Currency: money.CNY},
+		},
+		// 合计为零，但两个币种各自不平衡
+		"币种不平": {
+			{AccountID: a.ID, Amount: money.FromUnits(-10), Currency: money.CNY},
+			{AccountID: b.ID, Amount: money.FromUnits(10), Currency: money.USD},
+		},
+		"只有一条分录": {
+			{AccountID: a.ID, Amount: 0, Currency: money.CNY},
+		},
+	}
+	for name, postings := range tests {
+		err := post(db, &JournalEntry{Description: name, Postings: postings})
+		if !errors.Is(err, ErrUnbalancedEntry) {
+			t.Errorf("%s: err = %v, 期望 ErrUnbalancedEntry", name, err)
+		}
+	}
+}
+
+func TestLedgerMigrationRecordsOpeningBalances(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	if _, err := migrate.Up(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	// 回滚到账本迁移 0007 之前
+	migrations, err := migrate.Load("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := 0
+	for _, m := range migrations {
+		if m.Version >= 7 {
+			steps++
+		}
+	}
+	if _, err := migrate.Down(ctx, db, steps); err != nil {
+		t.Fatal(err)
+	}
+
+	// 账本迁移之前创建的账户没有分录；使用 SQL 插入，不依赖之后的迁移添加的列
+	for _, balance := range []money.Money{money.MustParse("100.10"), 0, money.FromUnits(50)} {
+		if err := db.Exec("INSERT INTO accounts (balance) VALUES (?)", balance).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := migrate.Up(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	if discrepancies, err := VerifyLedger(db); err != nil || len(discrepancies) != 0 {
+		t.Errorf("VerifyLedger = %+v, %v", discrepancies, err)
+	}
+	external, err := AccountPostings(db, ExternalAccountID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(external) != 1 || external[0].Amount != money.MustParse("-150.10") {
+		t.Errorf("外部账户分录 = %+v, 期望一笔 -150.10", external)
+	}
+}
@@ -0,0 +1,205 @@
+package gormSqlTwo
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm/money"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AccountTypeStandard 普通账户，开户时的默认类型
+const AccountTypeStandard = "standard"
+
+// PolicyName 转账限制的名称，与 account_policies 表的列名相同
+type PolicyName string
+
+const (
+	// PolicyMaxPerTransaction 单笔转出金额上限
+	PolicyMaxPerTransaction PolicyName = "max_per_transaction"
+	// PolicyDailyLimit 每个自然日（UTC）累计转出金额上限，包括本笔
+	PolicyDailyLimit PolicyName = "daily_limit"
+	// PolicyOverdraftLimit 允许透支的金额，转出后余额不低于它的相反数
+	PolicyOverdraftLimit PolicyName = "overdraft_limit"
+	// PolicyMinimumBalance 转出后账户至少保留的余额
+	PolicyMinimumBalance PolicyName = "minimum_balance"
+)
+
+var policyDescriptions = map[PolicyName]string{
+	PolicyMaxPerTransaction: "单笔转出上限",
+	PolicyDailyLimit:        "每日转出上限",
+	PolicyOverdraftLimit:    "透支额度",
+	PolicyMinimumBalance:    "最低余额",
+}
+
+// String 返回限制的中文名称
+func (p PolicyName) String() string {
+	if d, ok := policyDescriptions[p]; ok {
+		return d
+	}
+	return string(p)
+}
+
+// AccountPolicy 转账限制，AccountID 和 AccountType 二选一
+// 账户的设置优先于账户类型的设置，逐项合并；字段为 nil 表示不限制。金额以账户币种计。
+// 没有任何限制时转出后余额不能为负数（余额不足返回 ErrInsufficientFunds）。
+type AccountPolicy struct {
+	ID                uint         `gorm:"primaryKey;autoIncrement"`
+	AccountID         *uint        `gorm:"uniqueIndex:idx_account_policies_account_id"`
+	AccountType       *string      `gorm:"type:varchar(20);uniqueIndex:idx_account_policies_account_type"`
+	MaxPerTransaction *money.Money `gorm:"type:decimal(12,2)"`
+	DailyLimit        *money.Money `gorm:"type:decimal(12,2)"`
+	OverdraftLimit    *money.Money `gorm:"type:decimal(12,2)"`
+	MinimumBalance    *money.Money `gorm:"type:decimal(12,2)"`
+	UpdatedAt         time.Time
+}
+
+// PolicyViolation 转账违反了账户的某项转账限制
+type PolicyViolation struct {
+	AccountID uint
+	Policy    PolicyName
+	// Limit 限制的金额
+	Limit money.Money
+	// Actual 违反限制的值：单笔金额、当日累计转出金额（包括本笔）、透支金额或转出后的余额
+	Actual money.Money
+}
+
+func (e *PolicyViolation) Error() string {
+	return fmt.Sprintf("账户%d 违反转账限制 %s（%s）: 限制 %s，实际 %s", e.AccountID, e.Policy, string(e.Policy), e.Limit, e.Actual)
+}
+
+// Unwrap 使 errors.Is(err, ErrPolicyViolation) 成立
+func (e *PolicyViolation) Unwrap() error {
+	return ErrPolicyViolation
+}
+
+// SetAccountPolicy 保存账户（AccountID）或账户类型（AccountType）的转账限制，已有设置时整体替换
+func SetAccountPolicy(db *gorm.DB, policy AccountPolicy) (*AccountPolicy, error) {
+	if (policy.AccountID == nil) == (policy.AccountType == nil) {
+		return nil, errors.New("转账限制必须且只能指定账户或账户类型之一")
+	}
+	limits := []struct {
+		name  PolicyName
+		limit *money.Money
+	}{
+		{PolicyMaxPerTransaction, policy.MaxPerTransaction},
+		{PolicyDailyLimit, policy.DailyLimit},
+		{PolicyOverdraftLimit, policy.OverdraftLimit},
+		{PolicyMinimumBalance, policy.MinimumBalance},
+	}
+	for _, l := range limits {
+		if l.limit != nil && *l.limit < 0 {
+			return nil, fmt.Errorf("%s不能为负数: %s", l.name, *l.limit)
+		}
+	}
+
+	key := clause.Column{Name: "account_id"}
+	if policy.AccountType != nil {
+		key = clause.Column{Name: "account_type"}
+	}
+	policy.ID = 0
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{key},
+		DoUpdates: clause.AssignmentColumns([]string{"max_per_transaction", "daily_limit", "overdraft_limit", "minimum_balance", "updated_at"}),
+	}).Create(&policy).Error
+	if err != nil {
+		return nil, fmt.Errorf("保存转账限制失败: %w", err)
+	}
+	return &policy, nil
+}
+
+// SetAccountType 修改账户类型
+func SetAccountType(db *gorm.DB, accountID uint, accountType string) error {
+	if accountType == "" {
+		return errors.New("账户类型不能为空")
+	}
+	result := db.Model(&Account{}).Where("id = ?", accountID).Update("type", accountType)
+	if result.Error != nil {
+		return fmt.Errorf("修改账户 %d 的类型失败: %w", accountID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: 账户%d", ErrAccountNotFound, accountID)
+	}
+	return nil
+}
+
+// EffectivePolicy 返回账户实际生效的转账限制：账户类型的设置，再用账户自己的设置逐项覆盖
+func EffectivePolicy(db *gorm.DB, account Account) (AccountPolicy, error) {
+	var policies []AccountPolicy
+	if err := db.Where("account_id = ? OR account_type = ?", account.ID, account.Type).Find(&policies).Error; err != nil {
+		return AccountPolicy{}, fmt.Errorf("查询账户 %d 的转账限制失败: %w", account.ID, err)
+	}
+	effective := AccountPolicy{AccountID: &account.ID}
+	// 先合并账户类型的设置，再合并账户的设置
+	for _, byAccount := range []bool{false, true} {
+		for _, p := range policies {
+			if (p.AccountID != nil) != byAccount {
+				continue
+			}
+			effective.MaxPerTransaction = firstSet(p.MaxPerTransaction, effective.MaxPerTransaction)
+			effective.DailyLimit = firstSet(p.DailyLimit, effective.DailyLimit)
+			effective.OverdraftLimit = firstSet(p.OverdraftLimit, effective.OverdraftLimit)
+			effective.MinimumBalance = firstSet(p.MinimumBalance, effective.MinimumBalance)
+		}
+	}
+	return effective, nil
+}
+
+// firstSet 返回第一个不为 nil 的值
+func firstSet(values ...*money.Money) *money.Money {
+	for _, v := range values {
+		if v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// checkOutgoing 在转账事务中检查转出账户的单笔上限和每日累计上限
+// 当日累计按当天完成的转出金额统计，包括之后被冲正的转账，不包括冲正交易本身；
+// 同一事务中先完成的转账（例如批量转账中前面的转账）也计算在内。
+func checkOutgoing(tx *gorm.DB, policy AccountPolicy, accountID uint, amount money.Money) error {
+	if limit := policy.MaxPerTransaction; limit != nil && amount > *limit {
+		return &PolicyViolation{AccountID: accountID, Policy: PolicyMaxPerTransaction, Limit: *limit, Actual: amount}
+	}
+	if limit := policy.DailyLimit; limit != nil {
+		start := tx.NowFunc().Truncate(24 * time.Hour)
+		var total struct{ Total money.Money }
+		if err := tx.Model(&Transaction{}).
+			Select("COALESCE(SUM(amount), 0) AS total").
+			Where("from_account_id = ? AND status IN ? AND reversal_of_id IS NULL AND completed_at >= ?",
+				accountID, []TransferStatus{StatusCompleted, StatusReversed}, start).
+			Scan(&total).Error; err != nil {
+			return fmt.Errorf("统计账户 %d 当日转出金额失败: %w", accountID, err)
+		}
+		if spent := total.Total + amount; spent > *limit {
+			return &PolicyViolation{AccountID: accountID, Policy: PolicyDailyLimit, Limit: *limit, Actual: spent}
+		}
+	}
+	return nil
+}
+
+// floorViolation 转出后余额低于 balanceFloor 返回的下限，balance 为转出后的余额
+func floorViolation(policy AccountPolicy, name PolicyName, accountID uint, balance money.Money) *PolicyViolation {
+	if name == PolicyOverdraftLimit {
+		return &PolicyViolation{AccountID: accountID, Policy: name, Limit: *policy.OverdraftLimit, Actual: -balance}
+	}
+	return &PolicyViolation{AccountID: accountID, Policy: name, Limit: *policy.MinimumBalance, Actual: balance}
+}
+
+// balanceFloor 返回转出后余额的下限，以及决定下限的限制（没有限制时为空，下限为 0）
+// 同时设置透支额度和最低余额时取较高的下限。
+func balanceFloor(policy AccountPolicy) (money.Money, PolicyName) {
+	var floor money.Money
+	var name PolicyName
+	if policy.OverdraftLimit != nil {
+		floor, name = -*policy.OverdraftLimit, PolicyOverdraftLimit
+	}
+	if policy.MinimumBalance != nil && (name == "" || *policy.MinimumBalance > floor) {
+		floor, name = *policy.MinimumBalance, PolicyMinimumBalance
+	}
+	return floor, name
+}
@@ -0,0 +1,177 @@
+package gormSqlTwo
+
+import (
+	"errors"
+	"testing"
+
+	"gorm/money"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+// violation 断言 err 是违反 policy 的 *PolicyViolation
+func violation(t *testing.T, err error, policy PolicyName) *PolicyViolation {
+	t.Helper()
+	var pv *PolicyViolation
+	if !errors.Is(err, ErrPolicyViolation) || !errors.As(err, &pv) || pv.Policy != policy {
+		t.Fatalf("err = %v, 期望违反 %s", err, policy)
+	}
+	return pv
+}
+
+func TestPolicyMaxPerTransaction(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(500), 0)
+	if _, err := SetAccountPolicy(db, AccountPolicy{AccountID: &a.ID, MaxPerTransaction: ptr(money.FromUnits(100))}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := transferMoney(db, a.ID, b.ID, money.FromUnits(100)); err != nil {
+		t.Fatalf("等于上限的转账: %v", err)
+	}
+	pv := violation(t, transferMoney(db, a.ID, b.ID, money.MustParse("100.01")), PolicyMaxPerTransaction)
+	if pv.AccountID != a.ID || pv.Limit != money.FromUnits(100) || pv.Actual != money.MustParse("100.01") {
+		t.Errorf("PolicyViolation = %+v", pv)
+	}
+	// 限制只作用于转出账户
+	if err := transferMoney(db, b.ID, a.ID, money.FromUnits(100)); err != nil {
+		t.Errorf("转入账户不受限制: %v", err)
+	}
+}
+
+func TestPolicyDailyLimit(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(500), 0)
+	if _, err := SetAccountPolicy(db, AccountPolicy{AccountID: &a.ID, DailyLimit: ptr(money.FromUnits(150))}); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := Transfer(db, TransferRequest{FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.FromUnits(100)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pv := violation(t, transferMoney(db, a.ID, b.ID, money.FromUnits(60)), PolicyDailyLimit)
+	if pv.Actual != money.FromUnits(160) {
+		t.Errorf("当日累计 = %s, 期望 160.00", pv.Actual)
+	}
+	// 失败的转账不计入累计
+	if err := transferMoney(db, a.ID, b.ID, money.FromUnits(50)); err != nil {
+		t.Fatalf("累计 150: %v", err)
+	}
+
+	// 冲正不受转入账户的限制，被冲正的转账仍计入当日累计
+	if _, err := ReverseTransfer(db, first.ID, "测试"); err != nil {
+		t.Fatal(err)
+	}
+	violation(t, transferMoney(db, a.ID, b.ID, money.FromCents(1)), PolicyDailyLimit)
+
+	// 批量转账中前面的转账计入累计
+	db, a, b = setupAccounts(t, money.FromUnits(500), 0)
+	if _, err := SetAccountPolicy(db, AccountPolicy{AccountID: &a.ID, DailyLimit: ptr(money.FromUnits(150))}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = BatchTransfer(db, []TransferRequest{
+		{FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.FromUnits(100)},
+		{FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.FromUnits(100)},
+	})
+	var be *BatchError
+	if !errors.As(err, &be) || be.Index != 1 {
+		t.Fatalf("err = %v, 期望第 2 笔失败", err)
+	}
+	violation(t, err, PolicyDailyLimit)
+	if got := balanceOf(t, db, a.ID); got != money.FromUnits(500) {
+		t.Errorf("账户A余额 = %s, 期望 500.00", got)
+	}
+}
+
+func TestPolicyOverdraftAndMinimumBalance(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(100), 0)
+
+	// 透支额度 50：余额可以降到 -50
+	if _, err := SetAccountPolicy(db, AccountPolicy{AccountID: &a.ID, OverdraftLimit: ptr(money.FromUnits(50))}); err != nil {
+		t.Fatal(err)
+	}
+	if err := transferMoney(db, a.ID, b.ID, money.FromUnits(150)); err != nil {
+		t.Fatalf("透支 50: %v", err)
+	}
+	if got := balanceOf(t, db, a.ID); got != money.FromUnits(-50) {
+		t.Errorf("账户A余额 = %s, 期望 -50.00", got)
+	}
+	pv := violation(t, transferMoney(db, a.ID, b.ID, money.FromCents(1)), PolicyOverdraftLimit)
+	if pv.Limit != money.FromUnits(50) || pv.Actual != money.MustParse("50.01") {
+		t.Errorf("PolicyViolation = %+v", pv)
+	}
+	if discrepancies, err := VerifyLedger(db); err != nil || len(discrepancies) != 0 {
+		t.Errorf("VerifyLedger = %+v, %v", discrepancies, err)
+	}
+
+	// 最低余额 20：B 最多转出 130
+	if _, err := SetAccountPolicy(db, AccountPolicy{AccountID: &b.ID, MinimumBalance: ptr(money.FromUnits(20))}); err != nil {
+		t.Fatal(err)
+	}
+	pv = violation(t, transferMoney(db, b.ID, a.ID, money.FromUnits(131)), PolicyMinimumBalance)
+	if pv.Limit != money.FromUnits(20) || pv.Actual != money.FromUnits(19) {
+		t.Errorf("PolicyViolation = %+v", pv)
+	}
+	if err := transferMoney(db, b.ID, a.ID, money.FromUnits(130)); err != nil {
+		t.Fatalf("保留最低余额: %v", err)
+	}
+}
+
+func TestPolicyByAccountType(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(500), money.FromUnits(500))
+	if err := SetAccountType(db, a.ID, "payroll"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SetAccountPolicy(db, AccountPolicy{AccountType: ptr("payroll"), MaxPerTransaction: ptr(money.FromUnits(10)), DailyLimit: ptr(money.FromUnits(100))}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 类型的限制只作用于该类型的账户
+	violation(t, transferMoney(db, a.ID, b.ID, money.FromUnits(20)), PolicyMaxPerTransaction)
+	if err := transferMoney(db, b.ID, a.ID, money.FromUnits(20)); err != nil {
+		t.Fatalf("普通账户: %v", err)
+	}
+
+	// 账户自己的设置逐项覆盖类型的设置
+	if _, err := SetAccountPolicy(db, AccountPolicy{AccountID: &a.ID, MaxPerTransaction: ptr(money.FromUnits(200))}); err != nil {
+		t.Fatal(err)
+	}
+	var account Account
+	if err := db.First(&account, a.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	policy, err := EffectivePolicy(db, account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *policy.MaxPerTransaction != money.FromUnits(200) || *policy.DailyLimit != money.FromUnits(100) || policy.OverdraftLimit != nil {
+		t.Errorf("EffectivePolicy = %+v", policy)
+	}
+	violation(t, transferMoney(db, a.ID, b.ID, money.FromUnits(150)), PolicyDailyLimit)
+	if err := transferMoney(db, a.ID, b.ID, money.FromUnits(100)); err != nil {
+		t.Fatalf("覆盖后的单笔上限: %v", err)
+	}
+
+	// 再次设置时整体替换
+	if _, err := SetAccountPolicy(db, AccountPolicy{AccountType: ptr("payroll")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := transferMoney(db, a.ID, b.ID, money.FromUnits(150)); err != nil {
+		t.Errorf("取消每日上限后: %v", err)
+	}
+}
+
+func TestSetAccountPolicyValidation(t *testing.T) {
+	db, a, _ := setupAccounts(t, 0, 0)
+	for _, p := range []AccountPolicy{
+		{},
+		{AccountID: &a.ID, AccountType: ptr("payroll")},
+		{AccountID: &a.ID, DailyLimit: ptr(money.FromUnits(-1))},
+	} {
+		if _, err := SetAccountPolicy(db, p); err == nil {
+			t.Errorf("SetAccountPolicy(%+v) 应当失败", p)
+		}
+	}
+	if err := SetAccountType(db, 9999, "payroll"); !errors.Is(err, ErrAccountNotFound) {
+		t.Errorf("err = %v, 期望 ErrAccountNotFound", err)
+	}
+}
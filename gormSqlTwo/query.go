@@ -0,0 +1,62 @@
+package gormSqlTwo
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultTransferQueryLimit TransferQuery 没有指定 Limit 时最多返回的记录数
+const DefaultTransferQueryLimit = 100
+
+// TransferQuery 查询转账记录的条件，零值字段不作为条件
+// 发起人、渠道和时间范围（迁移 0012）以及转出、转入账户（迁移 0014）都有索引，例如“发起人 X 上周的全部转账”：
+//
+//	FindTransfers(db, TransferQuery{InitiatorID: "X", From: weekStart, To: weekStart.AddDate(0, 0, 7)})
+type TransferQuery struct {
+	InitiatorID string
+	Channel     Channel
+	// AccountID 转出或转入账户
+	AccountID uint
+	Status    TransferStatus
+	// From 和 To 按受理时间（created_at）筛选 [From, To)
+	From time.Time
+	To   time.Time
+	// Limit 最多返回的记录数，不大于 0 时为 DefaultTransferQueryLimit
+	Limit int
+}
+
+// FindTransfers 按条件查询转账记录，按受理时间从新到旧排列
+func FindTransfers(db *gorm.DB, q TransferQuery) ([]Transaction, error) {
+	query := db.Model(&Transaction{})
+	if q.InitiatorID != "" {
+		query = query.Where("initiator_id = ?", q.InitiatorID)
+	}
+	if q.Channel != "" {
+		query = query.Where("channel = ?", q.Channel)
+	}
+	if q.AccountID != 0 {
+		// 转出和转入账户各有一个索引，数据库分别查找后合并（MySQL index merge、SQLite MULTI-INDEX OR）
+		query = query.Where("from_account_id = ? OR to_account_id = ?", q.AccountID, q.AccountID)
+	}
+	if q.Status != "" {
+		query = query.Where("status = ?", q.Status)
+	}
+	if !q.From.IsZero() {
+		query = query.Where("created_at >= ?", q.From.UTC())
+	}
+	if !q.To.IsZero() {
+		query = query.Where("created_at < ?", q.To.UTC())
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultTransferQueryLimit
+	}
+
+	var transactions []Transaction
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&transactions).Error; err != nil {
+		return nil, fmt.Errorf("查询转账记录失败: %w", err)
+	}
+	return transactions, nil
+}
@@ -0,0 +1,142 @@
+package gormSqlTwo
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm/money"
+)
+
+func TestTransferMetadata(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(500), 0)
+
+	before := time.Now()
+	transaction, err := Transfer(db, TransferRequest{FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.FromUnits(10), Memo: "房租", InitiatorID: "user-42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved Transaction
+	if err := db.First(&saved, transaction.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if saved.Memo != "房租" || saved.InitiatorID != "user-42" || saved.Channel != ChannelAPI {
+		t.Errorf("交易记录 = %+v, 期望保存备注、发起人和默认渠道", saved)
+	}
+	if saved.CreatedAt.Before(before.Add(-time.Second)) || saved.CompletedAt == nil || saved.CompletedAt.Before(saved.CreatedAt) {
+		t.Errorf("创建时间 %s, 完成时间 %v", saved.CreatedAt, saved.CompletedAt)
+	}
+
+	batch, err := BatchTransfer(db, []TransferRequest{
+		{FromAccountID: a.ID, ToAccountID: b.ID, Amount: 1},
+		{FromAccountID: a.ID, ToAccountID: b.ID, Amount: 1, Channel: ChannelAdmin},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batch[0].Channel != ChannelBatch || batch[1].Channel != ChannelAdmin {
+		t.Errorf("批量转账渠道 = %s、%s, 期望 batch、admin", batch[0].Channel, batch[1].Channel)
+	}
+	reversal, err := ReverseTransfer(db, transaction.ID, "退回")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reversal.Channel != ChannelAdmin {
+		t.Errorf("冲正渠道 = %s, 期望 admin", reversal.Channel)
+	}
+
+	for _, req := range []TransferRequest{
+		{FromAccountID: a.ID, ToAccountID: b.ID, Amount: 1, Channel: "web"},
+		{FromAccountID: a.ID, ToAccountID: b.ID, Amount: 1, Memo: strings.Repeat("备", 256)},
+		{FromAccountID: a.ID, ToAccountID: b.ID, Amount: 1, InitiatorID: strings.Repeat("x", 65)},
+	} {
+		if _, err := Transfer(db, req); err == nil {
+			t.Errorf("Transfer(%.40v) 应当失败", req)
+		}
+	}
+}
+
+func TestFindTransfers(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(500), money.FromUnits(500))
+	c, err := OpenAccount(db, money.CNY, money.FromUnits(500))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reqs := []TransferRequest{
+		{FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.FromUnits(1), InitiatorID: "alice"},
+		{FromAccountID: b.ID, ToAccountID: c.ID, Amount: money.FromUnits(2), InitiatorID: "alice", Channel: ChannelAdmin},
+		{FromAccountID: c.ID, ToAccountID: a.ID, Amount: money.FromUnits(3), InitiatorID: "bob"},
+		{FromAccountID: a.ID, ToAccountID: c.ID, Amount: money.FromUnits(999), InitiatorID: "alice"}, // 余额不足
+	}
+	ids := make([]uint, len(reqs))
+	for i, req := range reqs {
+		tx, err := Transfer(db, req)
+		if err == nil {
+			ids[i] = tx.ID
+		}
+	}
+	var failed Transaction
+	if err := db.Where("status = ?", StatusFailed).First(&failed).Error; err != nil {
+		t.Fatal(err)
+	}
+	ids[3] = failed.ID
+
+	// 第一笔放到 10 天前
+	now := time.Now().UTC()
+	if err := db.Model(&Transaction{}).Where("id = ?", ids[0]).Update("created_at", now.AddDate(0, 0, -10)).Error; err != nil {
+		t.Fatal(err)
+	}
+	weekAgo := now.AddDate(0, 0, -7)
+
+	tests := []struct {
+		name string
+		q    TransferQuery
+		want []uint
+	}{
+		{"发起人", TransferQuery{InitiatorID: "alice"}, []uint{ids[3], ids[1], ids[0]}},
+		{"发起人最近一周", TransferQuery{InitiatorID: "alice", From: weekAgo, To: now.Add(time.Minute)}, []uint{ids[3], ids[1]}},
+		{"渠道", TransferQuery{Channel: ChannelAdmin}, []uint{ids[1]}},
+		{"账户", TransferQuery{AccountID: a.ID, InitiatorID: "alice"}, []uint{ids[3], ids[0]}},
+		{"状态", TransferQuery{InitiatorID: "alice", Status: StatusCompleted}, []uint{ids[1], ids[0]}},
+		{"截止时间", TransferQuery{To: weekAgo}, []uint{ids[0]}},
+		{"条数", TransferQuery{Limit: 2}, []uint{ids[3], ids[2]}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FindTransfers(db, tt.q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var gotIDs []uint
+			for _, tx := range got {
+				gotIDs = append(gotIDs, tx.ID)
+			}
+			if !slices.Equal(gotIDs, tt.want) {
+				t.Errorf("结果 %v, 期望 %v", gotIDs, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindTransfersByAccountUsesIndexes(t *testing.T) {
+	db, a, _ := setupAccounts(t, money.FromUnits(500), 0)
+
+	// 转出和转入两个条件都通过索引查找（迁移 0014），不扫描整张表
+	var plan []struct{ Detail string }
+	if err := db.Raw("EXPLAIN QUERY PLAN SELECT * FROM transactions WHERE from_account_id = ? OR to_account_id = ? ORDER BY created_at DESC, id DESC LIMIT 100", a.ID, a.ID).
+		Scan(&plan).Error; err != nil {
+		t.Fatal(err)
+	}
+	var details []string
+	for _, row := range plan {
+		details = append(details, row.Detail)
+	}
+	text := strings.Join(details, "\n")
+	for _, index := range []string{"idx_transactions_from_account_created_at", "idx_transactions_to_account_created_at"} {
+		if !strings.Contains(text, index) {
+			t.Errorf("查询计划没有使用 %s:\n%s", index, text)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package gormSqlTwo
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm/database"
+	"gorm/money"
+	"gorm/schedule"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ScheduleStatus 定时转账的状态
+type ScheduleStatus string
+
+const (
+	// ScheduleActive 生效中，到期时由执行器发起转账
+	ScheduleActive ScheduleStatus = "active"
+	// ScheduleEnded 已过结束时间、不会再有下一期，或者转出、转入账户已销户
+	ScheduleEnded ScheduleStatus = "ended"
+	// ScheduleCancelled 已取消
+	ScheduleCancelled ScheduleStatus = "cancelled"
+)
+
+// RunStatus 定时转账一期的执行结果
+type RunStatus string
+
+const (
+	// RunCompleted 转账完成
+	RunCompleted RunStatus = "completed"
+	// RunFailed 转账失败，例如余额不足
+	RunFailed RunStatus = "failed"
+	// RunSkipped 错过的期次超过定时转账的 MaxCatchUp，更早的期次没有转账；记录在跳过的第一期上
+	RunSkipped RunStatus = "skipped"
+)
+
+// ScheduledTransfer 定时转账（长期指令），例如“每月 1 日从 A 向 B 转 100”
+// Schedule 为五段 cron 表达式或 @every 间隔（见 schedule 包），按 UTC 计算。
+type ScheduledTransfer struct {
+	ID            uint        `gorm:"primaryKey;autoIncrement" json:"id" label:"ID"`
+	FromAccountID uint        `json:"from_account_id" label:"转出账户"`
+	ToAccountID   uint        `json:"to_account_id" label:"转入账户"`
+	Amount        money.Money `gorm:"type:decimal(10,2)" json:"amount" label:"金额"`
+	Memo          string      `gorm:"type:varchar(255)" json:"memo,omitempty" label:"备注"`
+	InitiatorID   string      `gorm:"type:varchar(64)" json:"initiator_id,omitempty" label:"发起人"`
+	Schedule      string      `gorm:"type:varchar(100)" json:"schedule" label:"执行计划"`
+	// MaxCatchUp 停机后最多补执行的期次：0（默认）逐期补执行全部错过的期次；
+	// n 表示只执行最近的 n 期，更早的期次不转账，记为一条 skipped 执行记录。
+	// 例如只关心最新一期的 @every 1m 定时转账设为 1，停机一天后不会一次发起上千笔转账。
+	MaxCatchUp int `gorm:"not null;default:0" json:"max_catch_up" label:"最多补执行"`
+	// NextRunAt 下一期的计划执行时间，EndAt 结束时间（含），之后的期次不再执行；均以 UTC 保存
+	NextRunAt time.Time      `gorm:"index:idx_scheduled_transfers_status_next_run_at,priority:2" json:"next_run_at" label:"下次执行"`
+	EndAt     *time.Time     `json:"end_at,omitempty" label:"结束时间"`
+	Status    ScheduleStatus `gorm:"type:varchar(20);index:idx_scheduled_transfers_status_next_run_at,priority:1" json:"status" label:"状态"`
+	CreatedAt time.Time      `json:"created_at" label:"创建时间"`
+	UpdatedAt time.Time      `json:"updated_at" label:"更新时间"`
+}
+
+// ScheduledTransferRun 定时转账一期的执行结果，同一期只有一条记录
+type ScheduledTransferRun struct {
+	ID                  uint `gorm:"primaryKey;autoIncrement" json:"id" label:"ID"`
+	ScheduledTransferID uint `gorm:"uniqueIndex:idx_scheduled_transfer_runs_period,priority:1" json:"scheduled_transfer_id" label:"定时转账"`
+	// ScheduledAt 这一期的计划执行时间
+	ScheduledAt time.Time `gorm:"uniqueIndex:idx_scheduled_transfer_runs_period,priority:2" json:"scheduled_at" label:"计划时间"`
+	// TransactionID 这一期的交易记录；转账在写入交易记录前失败（例如账户不存在）时为 NULL
+	TransactionID *uint `json:"transaction_id,omitempty" label:"交易"`
+	// Status 执行结果：completed、failed 或 skipped
+	Status    RunStatus `gorm:"type:varchar(20)" json:"status" label:"状态"`
+	Error     string    `gorm:"type:varchar(255)" json:"error,omitempty" label:"失败原因"`
+	CreatedAt time.Time `json:"created_at" label:"执行时间"`
+}
+
+// CreateScheduledTransfer 创建定时转账
+// NextRunAt 为零值时从当前时间起算第一期；EndAt 为 nil 时不会自动结束。
+func CreateScheduledTransfer(db *gorm.DB, st ScheduledTransfer) (*ScheduledTransfer, error) {
+	sched, err := schedule.Parse(st.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	if st.MaxCatchUp < 0 {
+		return nil, fmt.Errorf("最多补执行的期次不能为负数: %d", st.MaxCatchUp)
+	}
+	if err := validateRequest(st.request()); err != nil {
+		return nil, err
+	}
+	var count int64
+	if err := db.Model(&Account{}).Where("id IN ?", []uint{st.FromAccountID, st.ToAccountID}).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("查询账户失败: %w", err)
+	}
+	if count != 2 {
+		return nil, fmt.Errorf("%w: 账户%d 或账户%d", ErrAccountNotFound, st.FromAccountID, st.ToAccountID)
+	}
+
+	if st.NextRunAt.IsZero() {
+		st.NextRunAt = sched.Next(db.NowFunc())
+		if st.NextRunAt.IsZero() {
+			return nil, fmt.Errorf("执行计划 %q 没有下一次执行时间", st.Schedule)
+		}
+	}
+	// 精确到秒，与 MySQL DATETIME(3) 读回的值一致
+	st.NextRunAt = st.NextRunAt.UTC().Truncate(time.Second)
+	if st.EndAt != nil {
+		end := st.EndAt.UTC()
+		if st.NextRunAt.After(end) {
+			return nil, fmt.Errorf("结束时间 %s 早于第一期 %s", end.Format(time.RFC3339), st.NextRunAt.Format(time.RFC3339))
+		}
+		st.EndAt = &end
+	}
+	st.ID = 0
+	st.Status = ScheduleActive
+	if err := db.Create(&st).Error; err != nil {
+		return nil, fmt.Errorf("创建定时转账失败: %w", err)
+	}
+	return &st, nil
+}
+
+// CancelScheduledTransfer 取消生效中的定时转账，已经执行的期次不受影响
+func CancelScheduledTransfer(db *gorm.DB, id uint) error {
+	return database.Transaction(db, func(tx *gorm.DB) error {
+		st, err := lockScheduledTransfer(tx, id)
+		if err != nil {
+			return err
+		}
+		if st.Status != ScheduleActive {
+			return fmt.Errorf("%w: 定时转账 %d 的状态为 %s", ErrScheduleNotActive, id, st.Status)
+		}
+		if err := tx.Model(&st).Update("status", ScheduleCancelled).Error; err != nil {
+			return fmt.Errorf("取消定时转账 %d 失败: %w", id, err)
+		}
+		return nil
+	})
+}
+
+// ScheduledTransfers 返回全部定时转账，按 ID 排列
+func ScheduledTransfers(db *gorm.DB) ([]ScheduledTransfer, error) {
+	var list []ScheduledTransfer
+	if err := db.Order("id").Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("查询定时转账失败: %w", err)
+	}
+	return list, nil
+}
+
+// ScheduledTransferRuns 返回定时转账的执行记录，按计划时间排列
+func ScheduledTransferRuns(db *gorm.DB, id uint) ([]ScheduledTransferRun, error) {
+	var runs []ScheduledTransferRun
+	if err := db.Where("scheduled_transfer_id = ?", id).Order("scheduled_at, id").Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("查询定时转账 %d 的执行记录失败: %w", id, err)
+	}
+	return runs, nil
+}
+
+// RunDueTransfers 执行 now 之前到期的定时转账，返回本次执行的期次
+// 停机期间错过的期次默认逐期补执行；定时转账设置了 MaxCatchUp 时只执行最近的 MaxCatchUp 期，更早的期次记为一条 skipped 记录。
+// 每一期在一个事务中锁定定时转账、转账、写入执行记录并推进到下一期，
+// 多个执行器同时运行或执行器在任意时刻重启都不会重复执行同一期。
+// 转账失败（例如余额不足）同样记录执行结果并推进到下一期，不会重试；
+// 转出或转入账户已销户时记录这一期的失败并结束定时转账，之后不再执行。
+// 其他错误不影响别的定时转账，全部处理完后一起返回。
+func RunDueTransfers(db *gorm.DB, now time.Time) ([]ScheduledTransferRun, error) {
+	now = now.UTC()
+	var ids []uint
+	if err := db.Model(&ScheduledTransfer{}).
+		Where("status = ? AND next_run_at <= ?", ScheduleActive, now).
+		Order("next_run_at, id").
+		Pluck("id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("查询到期的定时转账失败: %w", err)
+	}
+
+	var runs []ScheduledTransferRun
+	var errs []error
+	for _, id := range ids {
+		for {
+			run, err := runNextPeriod(db, id, now)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("执行定时转账 %d 失败: %w", id, err))
+				break
+			}
+			if run == nil {
+				break
+			}
+			runs = append(runs, *run)
+		}
+	}
+	return runs, errors.Join(errs...)
+}
+
+// runNextPeriod 执行定时转账已到期的下一期，没有到期的期次时返回 nil
+// 转账、执行记录和下一期时间在同一个事务中提交：行锁使同一时刻只有一个执行器处理这个定时转账，
+// 执行记录的唯一索引和按期次生成的幂等键保证同一期最多转账一次。
+func runNextPeriod(db *gorm.DB, id uint, now time.Time) (*ScheduledTransferRun, error) {
+	var run *ScheduledTransferRun
+	err := database.Transaction(db, func(tx *gorm.DB) error {
+		run = nil
+		st, err := lockScheduledTransfer(tx, id)
+		if err != nil {
+			return err
+		}
+		// 加锁前其他执行器可能已经执行了这一期
+		if st.Status != ScheduleActive || st.NextRunAt.After(now) {
+			return nil
+		}
+		sched, err := schedule.Parse(st.Schedule)
+		if err != nil {
+			return err
+		}
+		period := st.NextRunAt.UTC()
+		if st.EndAt != nil && period.After(*st.EndAt) {
+			return endSchedule(tx, &st)
+		}
+		if st.MaxCatchUp > 0 {
+			if skipped, err := skipMissedPeriods(tx, &st, sched, now); err != nil || skipped != nil {
+				run = skipped
+				return err
+			}
+		}
+
+		req := st.request()
+		req.IdempotencyKey = fmt.Sprintf("scheduled-%d-%d", st.ID, period.Unix())
+		req.Channel = ChannelScheduled
+		result := ScheduledTransferRun{ScheduledTransferID: st.ID, ScheduledAt: period, Status: RunCompleted}
+		transaction, transferErr := Transfer(tx, req)
+		switch {
+		case transferErr == nil:
+			result.TransactionID = &transaction.ID
+		case database.IsRetryable(transferErr):
+			// 整个事务由 database.Transaction 重试，这一期还没有执行
+			return transferErr
+		default:
+			result.Status = RunFailed
+			result.Error = truncate(transferErr.Error(), 255)
+			var te *TransferError
+			if errors.As(transferErr, &te) && te.TransactionID != 0 {
+				result.TransactionID = &te.TransactionID
+			}
+		}
+		if err := tx.Create(&result).Error; err != nil {
+			return fmt.Errorf("记录 %s 这一期的执行结果失败: %w", period.Format(time.RFC3339), err)
+		}
+		run = &result
+
+		// 销户不能恢复，之后的每一期都会失败
+		if errors.Is(transferErr, ErrAccountClosed) {
+			return endSchedule(tx, &st)
+		}
+		next := sched.Next(period)
+		if next.IsZero() || (st.EndAt != nil && next.After(*st.EndAt)) {
+			if err := endSchedule(tx, &st); err != nil {
+				return err
+			}
+		} else if err := tx.Model(&st).Update("next_run_at", next).Error; err != nil {
+			return fmt.Errorf("更新定时转账 %d 的下次执行时间失败: %w", st.ID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// skipMissedPeriods 到期的期次超过 st.MaxCatchUp 时，把下一期推进到最近的 MaxCatchUp 期中的第一期，
+// 在跳过的第一期上写入一条 skipped 记录并返回；没有需要跳过的期次时返回 nil
+func skipMissedPeriods(tx *gorm.DB, st *ScheduledTransfer, sched schedule.Schedule, now time.Time) (*ScheduledTransferRun, error) {
+	until := now
+	if st.EndAt != nil && st.EndAt.Before(until) {
+		until = *st.EndAt
+	}
+	first := st.NextRunAt.UTC()
+	skipped, resume := schedule.Skip(sched, first, until, st.MaxCatchUp)
+	if skipped == 0 {
+		return nil, nil
+	}
+
+	run := ScheduledTransferRun{
+		ScheduledTransferID: st.ID,
+		ScheduledAt:         first,
+		Status:              RunSkipped,
+		Error: fmt.Sprintf("错过的期次超过 %d 期，跳过 %s 起的 %d 期，从 %s 继续执行",
+			st.MaxCatchUp, first.Format(time.RFC3339), skipped, resume.Format(time.RFC3339)),
+	}
+	if err := tx.Create(&run).Error; err != nil {
+		return nil, fmt.Errorf("记录定时转账 %d 跳过的期次失败: %w", st.ID, err)
+	}
+	if err := tx.Model(st).Update("next_run_at", resume).Error; err != nil {
+		return nil, fmt.Errorf("更新定时转账 %d 的下次执行时间失败: %w", st.ID, err)
+	}
+	return &run, nil
+}
+
+// request 定时转账每一期的转账请求
+func (st ScheduledTransfer) request() TransferRequest {
+	return TransferRequest{
+		FromAccountID: st.FromAccountID,
+		ToAccountID:   st.ToAccountID,
+		Amount:        st.Amount,
+		Memo:          st.Memo,
+		InitiatorID:   st.InitiatorID,
+	}
+}
+
+// lockScheduledTransfer 在事务中锁定定时转账
+func lockScheduledTransfer(tx *gorm.DB, id uint) (ScheduledTransfer, error) {
+	var st ScheduledTransfer
+	if err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).First(&st, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return st, fmt.Errorf("%w: %d", ErrScheduledTransferNotFound, id)
+		}
+		return st, fmt.Errorf("锁定定时转账 %d 失败: %w", id, err)
+	}
+	return st, nil
+}
+
+// endSchedule 把定时转账标记为已结束
+func endSchedule(tx *gorm.DB, st *ScheduledTransfer) error {
+	if err := tx.Model(st).Update("status", ScheduleEnded).Error; err != nil {
+		return fmt.Errorf("结束定时转账 %d 失败: %w", st.ID, err)
+	}
+	return nil
+}
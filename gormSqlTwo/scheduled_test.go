@@ -0,0 +1,340 @@
+package gormSqlTwo
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm/money"
+)
+
+func TestRunDueTransfers(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(500), 0)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	st, err := CreateScheduledTransfer(db, ScheduledTransfer{
+		FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.FromUnits(100),
+		Memo: "房租", InitiatorID: "alice", Schedule: "@monthly", NextRunAt: start,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 还没有到期
+	if runs, err := RunDueTransfers(db, start.Add(-time.Minute)); err != nil || len(runs) != 0 {
+		t.Fatalf("到期前执行了 %d 期, err = %v", len(runs), err)
+	}
+
+	// 停机错过的 1 月、2 月和 3 月逐期补执行
+	runs, err := RunDueTransfers(db, time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 3 {
+		t.Fatalf("执行了 %d 期, 期望 3", len(runs))
+	}
+	for i, run := range runs {
+		if want := start.AddDate(0, i, 0); !run.ScheduledAt.Equal(want) || run.Status != RunCompleted || run.TransactionID == nil {
+			t.Errorf("第 %d 期 = %+v, 期望 %s 完成", i+1, run, want)
+		}
+	}
+	if got := balanceOf(t, db, b.ID); got != money.FromUnits(300) {
+		t.Errorf("账户B余额 = %s, 期望 300.00", got)
+	}
+
+	// 重复执行不会再转账
+	if runs, err := RunDueTransfers(db, time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)); err != nil || len(runs) != 0 {
+		t.Fatalf("重复执行了 %d 期, err = %v", len(runs), err)
+	}
+	var saved ScheduledTransfer
+	if err := db.First(&saved, st.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC); !saved.NextRunAt.Equal(want) || saved.Status != ScheduleActive {
+		t.Errorf("定时转账 = %+v, 期望下次执行 %s", saved, want)
+	}
+
+	transfers, err := FindTransfers(db, TransferQuery{Channel: ChannelScheduled})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transfers) != 3 || transfers[0].Memo != "房租" || transfers[0].InitiatorID != "alice" {
+		t.Errorf("定时转账的交易记录 = %+v", transfers)
+	}
+	if discrepancies, err := VerifyLedger(db); err != nil || len(discrepancies) != 0 {
+		t.Errorf("VerifyLedger = %v, %v", discrepancies, err)
+	}
+}
+
+func TestRunDueTransfersRecordsFailure(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(150), 0)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	st, err := CreateScheduledTransfer(db, ScheduledTransfer{
+		FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.FromUnits(100), Schedule: "@every 24h", NextRunAt: start,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 第 2 期余额不足：记录失败原因并推进到第 3 期，不影响之后的期次
+	runs, err := RunDueTransfers(db, start.Add(24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || runs[0].Status != RunCompleted || runs[1].Status != RunFailed || runs[1].Error == "" {
+		t.Fatalf("执行结果 = %+v, 期望第 1 期完成、第 2 期失败", runs)
+	}
+	var failed Transaction
+	if runs[1].TransactionID == nil {
+		t.Fatal("失败的期次没有记录交易")
+	}
+	if err := db.First(&failed, *runs[1].TransactionID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if failed.Status != StatusFailed || failed.Channel != ChannelScheduled {
+		t.Errorf("失败的交易记录 = %+v", failed)
+	}
+
+	if err := db.Model(&Account{}).Where("id = ?", a.ID).Update("balance", money.FromUnits(100)).Error; err != nil {
+		t.Fatal(err)
+	}
+	runs, err = RunDueTransfers(db, start.Add(48*time.Hour))
+	if err != nil || len(runs) != 1 || runs[0].Status != RunCompleted {
+		t.Fatalf("第 3 期 = %+v, err = %v", runs, err)
+	}
+	history, err := ScheduledTransferRuns(db, st.ID)
+	if err != nil || len(history) != 3 {
+		t.Fatalf("执行记录 %d 条, err = %v", len(history), err)
+	}
+}
+
+func TestRunDueTransfersEndAt(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(500), 0)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 2)
+	st, err := CreateScheduledTransfer(db, ScheduledTransfer{
+		FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.FromUnits(10), Schedule: "0 0 * * *", NextRunAt: start, EndAt: &end,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 结束时间当天（含）仍然执行，之后结束
+	runs, err := RunDueTransfers(db, start.AddDate(0, 1, 0))
+	if err != nil || len(runs) != 3 {
+		t.Fatalf("执行了 %d 期, err = %v, 期望 3", len(runs), err)
+	}
+	var saved ScheduledTransfer
+	if err := db.First(&saved, st.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if saved.Status != ScheduleEnded {
+		t.Errorf("状态 = %s, 期望 ended", saved.Status)
+	}
+	if err := CancelScheduledTransfer(db, st.ID); !errors.Is(err, ErrScheduleNotActive) {
+		t.Errorf("取消已结束的定时转账: err = %v, 期望 ErrScheduleNotActive", err)
+	}
+}
+
+func TestCancelScheduledTransfer(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(500), 0)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	st, err := CreateScheduledTransfer(db, ScheduledTransfer{
+		FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.FromUnits(10), Schedule: "@daily", NextRunAt: start,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CancelScheduledTransfer(db, st.ID); err != nil {
+		t.Fatal(err)
+	}
+	if runs, err := RunDueTransfers(db, start.AddDate(0, 0, 5)); err != nil || len(runs) != 0 {
+		t.Fatalf("取消后执行了 %d 期, err = %v", len(runs), err)
+	}
+	if err := CancelScheduledTransfer(db, st.ID+1); !errors.Is(err, ErrScheduledTransferNotFound) {
+		t.Errorf("取消不存在的定时转账: err = %v", err)
+	}
+}
+
+func TestCreateScheduledTransferInvalid(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(500), 0)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := start.Add(-time.Hour)
+	for _, st := range []ScheduledTransfer{
+		{FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.FromUnits(10), Schedule: "每月"},
+		{FromAccountID: a.ID, ToAccountID: b.ID, Amount: 0, Schedule: "@daily"},
+		{FromAccountID: a.ID, ToAccountID: a.ID, Amount: money.FromUnits(10), Schedule: "@daily"},
+		{FromAccountID: a.ID, ToAccountID: b.ID + 100, Amount: money.FromUnits(10), Schedule: "@daily"},
+		{FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.FromUnits(10), Schedule: "0 0 30 2 *"},
+		{FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.FromUnits(10), Schedule: "@daily", NextRunAt: start, EndAt: &before},
+	} {
+		if _, err := CreateScheduledTransfer(db, st); err == nil {
+			t.Errorf("CreateScheduledTransfer(%+v) 应当失败", st)
+		}
+	}
+
+	// 没有指定第一期时从当前时间起算
+	st, err := CreateScheduledTransfer(db, ScheduledTransfer{FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.FromUnits(10), Schedule: "@every 1h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Until(st.NextRunAt); d < 59*time.Minute || d > time.Hour {
+		t.Errorf("第一期 %s, 期望约 1 小时后", st.NextRunAt)
+	}
+}
+
+func TestRunDueTransfersConcurrent(t *testing.T) {
+	db, ids := setupFileAccounts(t, 2, money.FromUnits(1000))
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := CreateScheduledTransfer(db, ScheduledTransfer{
+		FromAccountID: ids[0], ToAccountID: ids[1], Amount: money.FromUnits(10), Schedule: "@daily", NextRunAt: start,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 多个执行器同时执行，10 期中的每一期只转账一次
+	now := start.AddDate(0, 0, 9)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	total := 0
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runs, err := RunDueTransfers(db, now)
+			if err != nil {
+				t.Error(err)
+			}
+			mu.Lock()
+			total += len(runs)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if total != 10 {
+		t.Errorf("共执行 %d 期, 期望 10", total)
+	}
+	if got := balanceOf(t, db, ids[1]); got != money.FromUnits(1100) {
+		t.Errorf("转入账户余额 = %s, 期望 1100.00", got)
+	}
+}
+
+func TestRunDueTransfersCatchUpAll(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(500), 0)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := CreateScheduledTransfer(db, ScheduledTransfer{
+		FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.FromUnits(1), Schedule: "@every 1h", NextRunAt: start,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 默认不跳过：停机两天错过的 49 期全部补执行
+	runs, err := RunDueTransfers(db, start.Add(48*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 49 {
+		t.Fatalf("执行了 %d 期, 期望 49", len(runs))
+	}
+	for _, run := range runs {
+		if run.Status != RunCompleted {
+			t.Fatalf("执行结果 = %+v, 期望完成", run)
+		}
+	}
+	if got := balanceOf(t, db, b.ID); got != money.FromUnits(49) {
+		t.Errorf("账户B余额 = %s, 期望 49.00", got)
+	}
+}
+
+func TestRunDueTransfersMaxCatchUp(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(500), 0)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	st, err := CreateScheduledTransfer(db, ScheduledTransfer{
+		FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.FromUnits(1), Schedule: "@every 1m", NextRunAt: start, MaxCatchUp: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 停机一天错过 1441 期：跳过更早的 1438 期，只补执行最近的 3 期
+	now := start.Add(24 * time.Hour)
+	runs, err := RunDueTransfers(db, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 4 {
+		t.Fatalf("执行了 %d 期, 期望 1 条跳过记录和 3 期", len(runs))
+	}
+	if runs[0].Status != RunSkipped || !runs[0].ScheduledAt.Equal(start) || runs[0].TransactionID != nil || !strings.Contains(runs[0].Error, "1438") {
+		t.Errorf("跳过记录 = %+v", runs[0])
+	}
+	for i, run := range runs[1:] {
+		want := now.Add(time.Duration(i-2) * time.Minute)
+		if run.Status != RunCompleted || !run.ScheduledAt.Equal(want) {
+			t.Errorf("第 %d 期 = %+v, 期望 %s 完成", i+1, run, want)
+		}
+	}
+	if got := balanceOf(t, db, b.ID); got != money.FromUnits(3) {
+		t.Errorf("账户B余额 = %s, 期望 3.00", got)
+	}
+
+	var saved ScheduledTransfer
+	if err := db.First(&saved, st.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if want := now.Add(time.Minute); !saved.NextRunAt.Equal(want) {
+		t.Errorf("下次执行 = %s, 期望 %s", saved.NextRunAt, want)
+	}
+
+	// 错过的期次不超过 MaxCatchUp 时逐期补执行，不记录跳过
+	runs, err = RunDueTransfers(db, now.Add(3*time.Minute))
+	if err != nil || len(runs) != 3 {
+		t.Fatalf("执行了 %d 期, err = %v, 期望 3", len(runs), err)
+	}
+	for _, run := range runs {
+		if run.Status != RunCompleted {
+			t.Errorf("执行结果 = %+v, 期望完成", run)
+		}
+	}
+
+	if _, err := CreateScheduledTransfer(db, ScheduledTransfer{
+		FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.FromUnits(1), Schedule: "@daily", MaxCatchUp: -1,
+	}); err == nil {
+		t.Error("MaxCatchUp 为负数时应当失败")
+	}
+}
+
+func TestRunDueTransfersEndsOnClosedAccount(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(500), 0)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	st, err := CreateScheduledTransfer(db, ScheduledTransfer{
+		FromAccountID: a.ID, ToAccountID: b.ID, Amount: money.FromUnits(10), Schedule: "@daily", NextRunAt: start,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CloseAccount(db, b.ID, "admin", "销户"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 转入账户已销户：记录一次失败后结束，不会每一期都失败
+	runs, err := RunDueTransfers(db, start.AddDate(0, 0, 5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].Status != RunFailed || !strings.Contains(runs[0].Error, ErrAccountClosed.Error()) {
+		t.Fatalf("执行结果 = %+v, 期望一期失败", runs)
+	}
+	var saved ScheduledTransfer
+	if err := db.First(&saved, st.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if saved.Status != ScheduleEnded {
+		t.Errorf("状态 = %s, 期望 ended", saved.Status)
+	}
+	if runs, err := RunDueTransfers(db, start.AddDate(0, 0, 10)); err != nil || len(runs) != 0 {
+		t.Errorf("结束后执行了 %d 期, err = %v", len(runs), err)
+	}
+}
@@ -0,0 +1,273 @@
+package gormSqlTwo
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"gorm/database"
+	"gorm/money"
+
+	"gorm.io/gorm"
+)
+
+// 对账单
+// 对账单由账户的分录生成：按分录写入顺序排列，每一行是一笔支出或收入，并给出发生后的余额。
+// 期间按凭证时间取 [from, to)，from 或 to 为零值时不限制。
+
+// StatementPageSize AccountStatement 每页返回的最大行数
+const StatementPageSize = 100
+
+// StatementLine 对账单的一行
+type StatementLine struct {
+	PostingID      uint        `json:"posting_id" label:"分录"`
+	JournalEntryID uint        `json:"journal_entry_id" label:"凭证"`
+	TransactionID  *uint       `json:"transaction_id" label:"交易"`
+	Time           time.Time   `json:"time" label:"时间"`
+	Description    string      `json:"description" label:"摘要"`
+	Debit          money.Money `json:"debit" label:"支出"`
+	Credit         money.Money `json:"credit" label:"收入"`
+	// Balance 这一行发生后的余额
+	Balance money.Money `json:"balance" label:"余额"`
+}
+
+// Statement 账户在一个期间内的对账单（一页）
+type Statement struct {
+	AccountID uint           `json:"account_id" label:"账户"`
+	Currency  money.Currency `json:"currency" label:"币种"`
+	From      *time.Time     `json:"from,omitempty" label:"起始时间"`
+	To        *time.Time     `json:"to,omitempty" label:"截止时间"`
+	// OpeningBalance 和 ClosingBalance 是整个期间的期初和期末余额，与分页无关
+	OpeningBalance money.Money     `json:"opening_balance" label:"期初余额"`
+	ClosingBalance money.Money     `json:"closing_balance" label:"期末余额"`
+	Lines          []StatementLine `json:"lines" label:"明细"`
+	// NextCursor 下一页的游标，没有更多数据时为空
+	NextCursor string `json:"next_cursor,omitempty" label:"下一页"`
+}
+
+// ErrInvalidCursor 对账单的分页游标无法解析
+var ErrInvalidCursor = errors.New("无效的分页游标")
+
+// AccountStatement 返回账户在 [from, to) 期间的对账单，每页最多 StatementPageSize 行
+// cursor 为空时返回第一页，否则传入上一页的 NextCursor；游标按分录 ID 定位，翻页期间写入的新分录排在后面的页中。
+// 账户不存在时返回 ErrAccountNotFound。
+func AccountStatement(db *gorm.DB, accountID uint, from, to time.Time, cursor string) (*Statement, error) {
+	return accountStatement(db, accountID, from, to, cursor, StatementPageSize)
+}
+
+// FullAccountStatement 逐页读取账户在 [from, to) 期间的全部明细，合并为一份对账单，用于导出
+// 所有页在同一个只读事务中读取，导出期间写入的分录不会出现在结果中。
+func FullAccountStatement(db *gorm.DB, accountID uint, from, to time.Time) (*Statement, error) {
+	var full *Statement
+	err := database.ReadTransaction(db, func(tx *gorm.DB) error {
+		full = nil
+		cursor := ""
+		for {
+			page, err := AccountStatement(tx, accountID, from, to, cursor)
+			if err != nil {
+				return err
+			}
+			if full == nil {
+				full = page
+			} else {
+				full.Lines = append(full.Lines, page.Lines...)
+			}
+			if page.NextCursor == "" {
+				full.NextCursor = ""
+				return nil
+			}
+			cursor = page.NextCursor
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return full, nil
+}
+
+// accountStatement 在一个只读事务中读取一页对账单，期初余额、期末余额和本页明细来自同一个快照
+func accountStatement(db *gorm.DB, accountID uint, from, to time.Time, cursor string, limit int) (*Statement, error) {
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	var st *Statement
+	err = database.ReadTransaction(db, func(tx *gorm.DB) error {
+		st, err = readStatement(tx, accountID, from, to, after, limit)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+func readStatement(db *gorm.DB, accountID uint, from, to time.Time, after uint, limit int) (*Statement, error) {
+	var account Account
+	result := db.Limit(1).Find(&account, accountID)
+	if result.Error != nil {
+		return nil, fmt.Errorf("查询账户 %d 失败: %w", accountID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("%w: 账户%d", ErrAccountNotFound, accountID)
+	}
+	st := &Statement{AccountID: account.ID, Currency: account.Currency}
+	if !from.IsZero() {
+		st.From = &from
+	}
+	if !to.IsZero() {
+		st.To = &to
+	}
+
+	// 期初余额为期间之前的分录合计，期末余额再加上期间内的分录合计
+	if !from.IsZero() {
+		opening, err := sumPostings(db, accountID, time.Time{}, from, 0)
+		if err != nil {
+			return nil, err
+		}
+		st.OpeningBalance = opening
+	}
+	period, err := sumPostings(db, accountID, from, to, 0)
+	if err != nil {
+		return nil, err
+	}
+	st.ClosingBalance = st.OpeningBalance + period
+
+	// 本页第一行之前的余额：期初余额加上前面各页的分录
+	balance := st.OpeningBalance
+	if after > 0 {
+		previous, err := sumPostings(db, accountID, from, to, after)
+		if err != nil {
+			return nil, err
+		}
+		balance += previous
+	}
+
+	var rows []struct {
+		PostingID      uint
+		JournalEntryID uint
+		TransactionID  *uint
+		CreatedAt      time.Time
+		Description    string
+		Amount         money.Money
+	}
+	query := periodPostings(db, accountID, from, to).
+		Select("p.id AS posting_id, p.journal_entry_id, je.transaction_id, je.created_at, je.description, p.amount").
+		Where("p.id > ?", after).
+		Order("p.id").
+		Limit(limit + 1)
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("查询账户 %d 的对账单失败: %w", accountID, err)
+	}
+
+	// 多读一行判断是否还有下一页
+	if len(rows) > limit {
+		rows = rows[:limit]
+		st.NextCursor = encodeCursor(rows[limit-1].PostingID)
+	}
+	st.Lines = make([]StatementLine, 0, len(rows))
+	for _, r := range rows {
+		balance += r.Amount
+		line := StatementLine{
+			PostingID:      r.PostingID,
+			JournalEntryID: r.JournalEntryID,
+			TransactionID:  r.TransactionID,
+			Time:           r.CreatedAt,
+			Description:    r.Description,
+			Balance:        balance,
+		}
+		if r.Amount < 0 {
+			line.Debit = -r.Amount
+		} else {
+			line.Credit = r.Amount
+		}
+		st.Lines = append(st.Lines, line)
+	}
+	return st, nil
+}
+
+// periodPostings 账户在 [from, to) 期间的分录，按凭证时间筛选
+func periodPostings(db *gorm.DB, accountID uint, from, to time.Time) *gorm.DB {
+	query := db.Table("postings AS p").
+		Joins("JOIN journal_entries AS je ON je.id = p.journal_entry_id").
+		Where("p.account_id = ?", accountID)
+	if !from.IsZero() {
+		query = query.Where("je.created_at >= ?", from.UTC())
+	}
+	if !to.IsZero() {
+		query = query.Where("je.created_at < ?", to.UTC())
+	}
+	return query
+}
+
+// sumPostings 账户在 [from, to) 期间的分录合计；upTo 大于 0 时只统计 ID 不超过 upTo 的分录
+func sumPostings(db *gorm.DB, accountID uint, from, to time.Time, upTo uint) (money.Money, error) {
+	query := periodPostings(db, accountID, from, to)
+	if upTo > 0 {
+		query = query.Where("p.id <= ?", upTo)
+	}
+	var total struct{ Total money.Money }
+	if err := query.Select("COALESCE(SUM(p.amount), 0) AS total").Scan(&total).Error; err != nil {
+		return 0, fmt.Errorf("统计账户 %d 的分录失败: %w", accountID, err)
+	}
+	return total.Total, nil
+}
+
+// 游标是最后一行的分录 ID，编码后对调用方不透明
+func encodeCursor(postingID uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(postingID), 10)))
+}
+
+func decodeCursor(cursor string) (uint, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidCursor, cursor)
+	}
+	id, err := strconv.ParseUint(string(raw), 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidCursor, cursor)
+	}
+	return uint(id), nil
+}
+
+// WriteCSV 以 CSV 格式导出对账单明细：表头一行，之后每行一笔分录，末尾一行期末余额
+// 金额保留两位小数，时间为 UTC 的 RFC 3339 格式，交易为空表示不对应转账的凭证（例如开户存入）。
+func (s *Statement) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	records := [][]string{
+		{"时间", "分录", "凭证", "交易", "摘要", "支出", "收入", "余额", "币种"},
+		{"", "", "", "", "期初余额", "", "", s.OpeningBalance.String(), s.Currency.String()},
+	}
+	for _, l := range s.Lines {
+		transaction := ""
+		if l.TransactionID != nil {
+			transaction = strconv.FormatUint(uint64(*l.TransactionID), 10)
+		}
+		records = append(records, []string{
+			l.Time.UTC().Format(time.RFC3339),
+			strconv.FormatUint(uint64(l.PostingID), 10),
+			strconv.FormatUint(uint64(l.JournalEntryID), 10),
+			transaction,
+			l.Description,
+			l.Debit.String(),
+			l.Credit.String(),
+			l.Balance.String(),
+			s.Currency.String(),
+		})
+	}
+	if s.NextCursor == "" {
+		records = append(records, []string{"", "", "", "", "期末余额", "", "", s.ClosingBalance.String(), s.Currency.String()})
+	}
+	if err := cw.WriteAll(records); err != nil {
+		return fmt.Errorf("导出 CSV 失败: %w", err)
+	}
+	return nil
+}
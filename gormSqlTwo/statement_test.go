@@ -0,0 +1,172 @@
+package gormSqlTwo
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm/internal/testdb"
+	"gorm/money"
+
+	"gorm.io/gorm"
+)
+
+func TestAccountStatementPages(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(100), money.FromUnits(50))
+	for _, amount := range []int64{10, 20, 30} {
+		if err := transferMoney(db, a.ID, b.ID, money.FromUnits(amount)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := transferMoney(db, b.ID, a.ID, money.FromUnits(5)); err != nil {
+		t.Fatal(err)
+	}
+
+	// 开户 +100，转出 10、20、30，转入 5
+	var lines []StatementLine
+	cursor := ""
+	pages := 0
+	for {
+		st, err := accountStatement(db, a.ID, time.Time{}, time.Time{}, cursor, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st.OpeningBalance != 0 || st.ClosingBalance != money.FromUnits(45) || st.Currency != money.CNY {
+			t.Errorf("期初 %s, 期末 %s, 币种 %s, 期望 0.00、45.00、CNY", st.OpeningBalance, st.ClosingBalance, st.Currency)
+		}
+		lines = append(lines, st.Lines...)
+		pages++
+		if st.NextCursor == "" {
+			break
+		}
+		cursor = st.NextCursor
+	}
+	if pages != 3 {
+		t.Errorf("页数 = %d, 期望 3", pages)
+	}
+
+	want := []struct{ debit, credit, balance int64 }{
+		{0, 100, 100}, {10, 0, 90}, {20, 0, 70}, {30, 0, 40}, {0, 5, 45},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("明细 %d 行, 期望 %d", len(lines), len(want))
+	}
+	for i, w := range want {
+		l := lines[i]
+		if l.Debit != money.FromUnits(w.debit) || l.Credit != money.FromUnits(w.credit) || l.Balance != money.FromUnits(w.balance) {
+			t.Errorf("第 %d 行: 支出 %s 收入 %s 余额 %s, 期望 %d %d %d", i+1, l.Debit, l.Credit, l.Balance, w.debit, w.credit, w.balance)
+		}
+	}
+	if lines[0].TransactionID != nil || lines[1].TransactionID == nil {
+		t.Errorf("开户分录不应对应交易，转账分录应对应交易: %+v", lines[:2])
+	}
+
+	full, err := FullAccountStatement(db, a.ID, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(full.Lines) != len(want) || full.NextCursor != "" {
+		t.Errorf("FullAccountStatement 返回 %d 行, 游标 %q", len(full.Lines), full.NextCursor)
+	}
+}
+
+func TestAccountStatementPeriod(t *testing.T) {
+	db, a, b := setupAccounts(t, money.FromUnits(100), 0)
+	for _, amount := range []int64{10, 20, 30} {
+		if err := transferMoney(db, a.ID, b.ID, money.FromUnits(amount)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 把四张凭证分别放到 1 月 1 日至 4 日
+	day := func(d int) time.Time { return time.Date(2026, 1, d, 12, 0, 0, 0, time.UTC) }
+	var entries []JournalEntry
+	if err := db.Order("id").Find(&entries).Error; err != nil {
+		t.Fatal(err)
+	}
+	for i, e := range entries {
+		if err := db.Model(&e).Update("created_at", day(i+1)).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 期间为 1 月 2 日至 4 日（不含），包含转出 10 和 20
+	st, err := AccountStatement(db, a.ID, day(2).Add(-time.Hour), day(4).Add(-time.Hour), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.OpeningBalance != money.FromUnits(100) || st.ClosingBalance != money.FromUnits(70) {
+		t.Errorf("期初 %s, 期末 %s, 期望 100.00、70.00", st.OpeningBalance, st.ClosingBalance)
+	}
+	if len(st.Lines) != 2 || st.Lines[0].Debit != money.FromUnits(10) || st.Lines[1].Balance != money.FromUnits(70) {
+		t.Errorf("明细 = %+v", st.Lines)
+	}
+	if !st.Lines[0].Time.Equal(day(2)) {
+		t.Errorf("时间 = %s, 期望 %s", st.Lines[0].Time, day(2))
+	}
+
+	var buf bytes.Buffer
+	if err := st.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 表头、期初、两行明细、期末
+	if len(records) != 5 {
+		t.Fatalf("CSV %d 行, 期望 5: %v", len(records), records)
+	}
+	if got := records[2]; got[0] != "2026-01-02T12:00:00Z" || got[5] != "10.00" || got[7] != "90.00" || got[8] != "CNY" {
+		t.Errorf("CSV 明细 = %v", got)
+	}
+	if got := records[4]; got[4] != "期末余额" || got[7] != "70.00" {
+		t.Errorf("CSV 期末 = %v", got)
+	}
+}
+
+func TestAccountStatementErrors(t *testing.T) {
+	db, a, _ := setupAccounts(t, money.FromUnits(100), 0)
+
+	if _, err := AccountStatement(db, 9999, time.Time{}, time.Time{}, ""); !errors.Is(err, ErrAccountNotFound) {
+		t.Errorf("err = %v, 期望 ErrAccountNotFound", err)
+	}
+	for _, cursor := range []string{"!!", "YWJj"} {
+		if _, err := AccountStatement(db, a.ID, time.Time{}, time.Time{}, cursor); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("游标 %q: err = %v, 期望 ErrInvalidCursor", cursor, err)
+		}
+	}
+}
+
+func TestAccountStatementReadsOneSnapshot(t *testing.T) {
+	db, ids := createAccounts(t, testdb.OpenFileDeferred(t), 2, money.FromUnits(100))
+
+	// 对账单统计完期末余额后，另一个连接写入一笔转账
+	injected := false
+	err := db.Callback().Row().After("gorm:row").Register("test:transfer_during_statement", func(tx *gorm.DB) {
+		if injected || tx.Statement.Table != "p" {
+			return
+		}
+		injected = true
+		if err := transferMoney(db, ids[0], ids[1], money.FromUnits(30)); err != nil {
+			t.Errorf("并发转账失败: %v", err)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := AccountStatement(db, ids[0], time.Time{}, time.Time{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !injected {
+		t.Fatal("没有在读取对账单期间写入转账")
+	}
+	// 明细和期末余额来自同一个快照：都不包含读取期间写入的转账
+	if len(st.Lines) != 1 || st.ClosingBalance != money.FromUnits(100) || st.Lines[0].Balance != st.ClosingBalance {
+		t.Errorf("明细 %d 行, 期末 %s, 期望 1 行、100.00 且与最后一行余额一致", len(st.Lines), st.ClosingBalance)
+	}
+}
@@ -0,0 +1,79 @@
+// Package testdb 为测试提供独立的内存 SQLite 数据库，无需数据库服务即可运行
+package testdb
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"gorm/config"
+	"gorm/database"
+	"gorm/fixtures"
+
+	"gorm.io/gorm"
+)
+
+// Open 打开一个以测试名称命名的内存 SQLite 数据库，测试结束时自动关闭
+// 同一测试内的多个连接共享同一个数据库，不同测试之间相互隔离。
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	cfg := &config.Config{
+		Profile: "test",
+		Driver:  config.DriverSQLite,
+		DSN:     fmt.Sprintf("file:%s?mode=memory&cache=shared&_foreign_keys=on", url.PathEscape(t.Name())),
+	}
+	return open(t, cfg)
+}
+
+func open(t testing.TB, cfg *config.Config) *gorm.DB {
+	t.Helper()
+	db, err := database.Open(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("打开测试数据库失败: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取 sql.DB 失败: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// OpenFile 在测试的临时目录中打开文件 SQLite 数据库，测试结束时自动关闭
+// 内存数据库的共享缓存使用表级锁，并发写入会直接失败；文件数据库使用 BEGIN IMMEDIATE 开始事务，
+// 并在数据库被锁定时等待，适合并发测试。
+func OpenFile(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "test.db")
+	cfg := &config.Config{
+		Profile: "test",
+		Driver:  config.DriverSQLite,
+		DSN:     "file:" + path + "?_foreign_keys=on&_busy_timeout=10000&_txlock=immediate&_journal_mode=WAL",
+	}
+	return open(t, cfg)
+}
+
+// OpenFileDeferred 与 OpenFile 相同，但事务以默认的 BEGIN DEFERRED 开始
+// 并发事务先读后写时，写入会因为读取的快照已经过期而直接返回 SQLITE_BUSY（不等待 busy_timeout），
+// 用于测试事务真正并发执行时的冲突处理和 database.Transaction 的重试。
+func OpenFileDeferred(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "test.db")
+	cfg := &config.Config{
+		Profile: "test",
+		Driver:  config.DriverSQLite,
+		DSN:     "file:" + path + "?_foreign_keys=on&_busy_timeout=10000&_journal_mode=WAL",
+	}
+	return open(t, cfg)
+}
+
+// Context 返回使用 test 测试数据集的 context，示例的 Setup 会加载该集合的数据
+func Context() context.Context {
+	return fixtures.NewContext(context.Background(), fixtures.Options{Set: fixtures.SetTest})
+}
@@ -1,31 +1,23 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	advancedone "gorm/gormAdvanced"
+	"os"
+
+	"gorm/config"
+	"gorm/database"
 
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
 func main() {
-	// 尝试连接数据库
-	db, err := connectDatabase()
-	if err != nil {
-		fmt.Printf("警告: 数据库连接失败: %v\n", err)
-	} else {
-
-		//gormSql.Run(db)//基本CRUD操作
-		//gormSqlTwo.Run(db)//事务语句
-		//sqlxone.Run(db)//Sqlx题目一
-		//sqlxtwo.Run(db) //Sqlx题目二
-		advancedone.Run(db) //进阶gorm
-		//fmt.Println("数据库操作执行完毕")
-	}
+	os.Exit(execute(os.Args[1:], os.Stderr))
 }
 
-// connectDatabase 尝试连接到数据库
-func connectDatabase() (*gorm.DB, error) {
-	dsn := "root:123456@tcp(localhost:3306)/grom?charset=utf8mb4&parseTime=True&loc=Local"
-	return gorm.Open(mysql.Open(dsn), &gorm.Config{})
+// connectDatabase 按配置连接到数据库，数据库尚未就绪时在 cfg.ConnectTimeout 内重试
+func connectDatabase(cfg *config.Config) (*gorm.DB, error) {
+	// 输出到标准错误，标准输出只包含命令的结果，导出的 CSV、JSON 可以直接重定向到文件
+	fmt.Fprintf(os.Stderr, "使用 profile: %s, 驱动: %s\n", cfg.Profile, cfg.Driver)
+	return database.Open(context.Background(), cfg)
 }
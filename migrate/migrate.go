@@ -0,0 +1,272 @@
+// Package migrate 按版本执行 migrate/sql 下各方言的迁移脚本，并记录校验和
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm/database"
+
+	"gorm.io/gorm"
+)
+
+// 版本化数据库迁移
+// 迁移脚本按方言存放在 sql/<方言>/ 目录下，文件名格式为 <版本>_<名称>.up.sql 和 <版本>_<名称>.down.sql，
+// 每个版本必须同时提供 up 和 down 脚本。已执行的迁移记录在 schema_migrations 表中，
+// 包含 up 脚本的 SHA-256 校验和；执行 Up 前会校验已执行脚本是否被修改。
+//
+// 每个迁移在一个事务中执行。SQLite 的 DDL 支持事务回滚；MySQL 的 DDL 会隐式提交，
+// 迁移中途失败时需要手动处理已执行的语句。
+
+//go:embed sql
+var scripts embed.FS
+
+// TableName 迁移记录表
+const TableName = "schema_migrations"
+
+// ErrChecksumMismatch 已执行的迁移脚本在执行后被修改
+var ErrChecksumMismatch = errors.New("迁移脚本校验和不一致")
+
+// Migration 一个版本的迁移脚本
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // up 脚本的 SHA-256
+}
+
+// Record schema_migrations 表中的迁移记录
+type Record struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+func (Record) TableName() string {
+	return TableName
+}
+
+// Status 迁移状态
+type Status struct {
+	Version   int        `json:"version" label:"版本"`
+	Name      string     `json:"name" label:"名称"`
+	Applied   bool       `json:"applied" label:"已执行"`
+	AppliedAt *time.Time `json:"applied_at,omitempty" label:"执行时间"`
+	Modified  bool       `json:"modified" label:"脚本已修改"`
+}
+
+// createTableSQL 迁移记录表的建表语句，MySQL 和 SQLite 通用
+const createTableSQL = `CREATE TABLE IF NOT EXISTS ` + TableName + ` (
+	version BIGINT NOT NULL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	checksum CHAR(64) NOT NULL,
+	applied_at DATETIME NOT NULL
+)`
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Load 读取指定方言的全部迁移脚本，按版本升序排列
+func Load(dialect string) ([]Migration, error) {
+	dir := path.Join("sql", dialect)
+	entries, err := fs.ReadDir(scripts, dir)
+	if err != nil {
+		return nil, fmt.Errorf("方言 %s 没有迁移脚本: %w", dialect, err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, e := range entries {
+		m := fileNamePattern.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("无法识别的迁移文件名: %s", e.Name())
+		}
+		version, _ := strconv.Atoi(m[1])
+		data, err := fs.ReadFile(scripts, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("迁移版本 %d 重复: %s 和 %s", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(data)
+			sum := sha256.Sum256(data)
+			mig.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			mig.Down = string(data)
+		}
+	}
+
+	list := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" || mig.Down == "" {
+			return nil, fmt.Errorf("迁移 %04d_%s 缺少 up 或 down 脚本", mig.Version, mig.Name)
+		}
+		list = append(list, *mig)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// Up 执行所有未执行的迁移，返回本次执行的迁移
+func Up(ctx context.Context, db *gorm.DB) ([]Migration, error) {
+	db = db.WithContext(ctx)
+	migrations, applied, err := prepare(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(migrations, applied); err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := execScript(tx, m.Up); err != nil {
+				return err
+			}
+			return tx.Create(&Record{
+				Version:   m.Version,
+				Name:      m.Name,
+				Checksum:  m.Checksum,
+				AppliedAt: database.Now(),
+			}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("执行迁移 %04d_%s 失败: %w", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// Down 按版本倒序回滚最近执行的 steps 个迁移，返回本次回滚的迁移
+func Down(ctx context.Context, db *gorm.DB, steps int) ([]Migration, error) {
+	db = db.WithContext(ctx)
+	migrations, applied, err := prepare(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		m := migrations[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := execScript(tx, m.Down); err != nil {
+				return err
+			}
+			return tx.Delete(&Record{}, m.Version).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("回滚迁移 %04d_%s 失败: %w", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// Statuses 返回每个迁移的执行状态
+func Statuses(ctx context.Context, db *gorm.DB) ([]Status, error) {
+	migrations, applied, err := prepare(db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]Status, 0, len(migrations))
+	for _, m := range migrations {
+		s := Status{Version: m.Version, Name: m.Name}
+		if r, ok := applied[m.Version]; ok {
+			s.Applied = true
+			s.AppliedAt = &r.AppliedAt
+			s.Modified = r.Checksum != m.Checksum
+		}
+		list = append(list, s)
+	}
+	return list, nil
+}
+
+// prepare 确保迁移记录表存在，读取当前方言的迁移脚本和已执行记录
+func prepare(db *gorm.DB) ([]Migration, map[int]Record, error) {
+	migrations, err := Load(database.Dialect(db))
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := db.Exec(createTableSQL).Error; err != nil {
+		return nil, nil, fmt.Errorf("创建 %s 表失败: %w", TableName, err)
+	}
+
+	var records []Record
+	if err := db.Find(&records).Error; err != nil {
+		return nil, nil, fmt.Errorf("读取迁移记录失败: %w", err)
+	}
+	applied := make(map[int]Record, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+	return migrations, applied, nil
+}
+
+// verify 校验已执行迁移的脚本没有被修改
+func verify(migrations []Migration, applied map[int]Record) error {
+	for _, m := range migrations {
+		if r, ok := applied[m.Version]; ok && r.Checksum != m.Checksum {
+			return fmt.Errorf("%w: %04d_%s（记录 %.12s，当前 %.12s）", ErrChecksumMismatch, m.Version, m.Name, r.Checksum, m.Checksum)
+		}
+	}
+	return nil
+}
+
+// execScript 逐条执行脚本中以分号结尾的语句
+func execScript(tx *gorm.DB, script string) error {
+	for _, stmt := range splitStatements(script) {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitStatements 按行尾的分号切分语句，忽略空行和 -- 注释行
+func splitStatements(script string) []string {
+	var stmts []string
+	var buf strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		buf.WriteString(line)
+		buf.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, strings.TrimSpace(buf.String()))
+			buf.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(buf.String()); rest != "" {
+		stmts = append(stmts, rest)
+	}
+	return stmts
+}
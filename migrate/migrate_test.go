@@ -0,0 +1,144 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"gorm/database"
+	"gorm/internal/testdb"
+)
+
+var tables = []string{"students", "accounts", "transactions", "employees", "books", "users", "posts", "comments"}
+
+func TestDialectsDefineSameMigrations(t *testing.T) {
+	mysql, err := Load(database.DialectMySQL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlite, err := Load(database.DialectSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mysql) != len(sqlite) {
+		t.Fatalf("mysql %d 个迁移, sqlite %d 个迁移", len(mysql), len(sqlite))
+	}
+	for i := range mysql {
+		if mysql[i].Version != sqlite[i].Version || mysql[i].Name != sqlite[i].Name {
+			t.Errorf("迁移 #%d: mysql %04d_%s, sqlite %04d_%s", i, mysql[i].Version, mysql[i].Name, sqlite[i].Version, sqlite[i].Name)
+		}
+	}
+}
+
+func TestUpDownStatus(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+
+	all, err := Load(database.DialectSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done, err := Up(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != len(all) {
+		t.Fatalf("首次 Up 执行 %d 个迁移, 期望 %d", len(done), len(all))
+	}
+	for _, table := range tables {
+		if !db.Migrator().HasTable(table) {
+			t.Errorf("迁移后缺少表 %s", table)
+		}
+	}
+
+	// 重复执行不会再执行任何迁移
+	if done, err := Up(ctx, db); err != nil || len(done) != 0 {
+		t.Fatalf("重复 Up: 执行 %d 个迁移, err = %v", len(done), err)
+	}
+
+	// 回滚最近的一个迁移
+	done, err = Down(ctx, db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := all[len(all)-1]
+	if len(done) != 1 || done[0].Version != last.Version {
+		t.Fatalf("Down 回滚了 %+v, 期望 %04d_%s", done, last.Version, last.Name)
+	}
+
+	statuses, err := Statuses(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var applied []bool
+	for _, s := range statuses {
+		applied = append(applied, s.Applied)
+	}
+	want := make([]bool, len(all))
+	for i := range want {
+		want[i] = i < len(all)-1
+	}
+	if !reflect.DeepEqual(applied, want) {
+		t.Errorf("迁移状态 = %v, 期望 %v", applied, want)
+	}
+
+	// 再次 Up 只执行被回滚的迁移
+	done, err = Up(ctx, db)
+	if err != nil || len(done) != 1 || done[0].Version != last.Version {
+		t.Fatalf("再次 Up: %+v, err = %v", done, err)
+	}
+
+	// 全部回滚后不再有任何表
+	done, err = Down(ctx, db, len(all))
+	if err != nil || len(done) != len(all) {
+		t.Fatalf("全部回滚: %d 个迁移, err = %v", len(done), err)
+	}
+	for _, table := range tables {
+		if db.Migrator().HasTable(table) {
+			t.Errorf("全部回滚后表 %s 仍然存在", table)
+		}
+	}
+}
+
+func TestChecksumMismatch(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	if _, err := Up(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Model(&Record{}).Where("version = ?", 1).Update("checksum", "tampered").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Up(ctx, db); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Up err = %v, 期望 ErrChecksumMismatch", err)
+	}
+	statuses, err := Statuses(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !statuses[0].Modified {
+		t.Error("被修改的迁移应当标记为 Modified")
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- 注释
+CREATE TABLE a (
+	id INT
+);
+
+CREATE INDEX idx_a ON a (id);
+DROP TABLE b`
+	want := []string{
+		"CREATE TABLE a (\n\tid INT\n);",
+		"CREATE INDEX idx_a ON a (id);",
+		"DROP TABLE b",
+	}
+	if got := splitStatements(script); !reflect.DeepEqual(got, want) {
+		t.Errorf("splitStatements = %q, 期望 %q", got, want)
+	}
+}
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS students (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	name VARCHAR(100),
+	age INT,
+	grade VARCHAR(50),
+	PRIMARY KEY (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS accounts (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	balance DECIMAL(10, 2),
+	PRIMARY KEY (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS transactions (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	from_account_id BIGINT UNSIGNED,
+	to_account_id BIGINT UNSIGNED,
+	amount DECIMAL(10, 2),
+	PRIMARY KEY (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS employees (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	name VARCHAR(100) NOT NULL,
+	department VARCHAR(100) NOT NULL,
+	salary DECIMAL(10, 2) NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
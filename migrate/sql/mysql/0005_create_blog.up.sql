@@ -0,0 +1,42 @@
+CREATE TABLE IF NOT EXISTS users (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	username VARCHAR(50) NOT NULL,
+	email VARCHAR(100) NOT NULL,
+	password VARCHAR(255) NOT NULL,
+	nickname VARCHAR(50),
+	post_count BIGINT DEFAULT 0,
+	created_at DATETIME(3) NULL,
+	updated_at DATETIME(3) NULL,
+	PRIMARY KEY (id),
+	UNIQUE INDEX idx_users_username (username),
+	UNIQUE INDEX idx_users_email (email)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS posts (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	title VARCHAR(200) NOT NULL,
+	content TEXT NOT NULL,
+	user_id BIGINT UNSIGNED NOT NULL,
+	view_count BIGINT DEFAULT 0,
+	comment_status VARCHAR(20) DEFAULT '有评论',
+	created_at DATETIME(3) NULL,
+	updated_at DATETIME(3) NULL,
+	PRIMARY KEY (id),
+	INDEX idx_posts_title (title),
+	INDEX idx_posts_user_id (user_id),
+	CONSTRAINT fk_users_posts FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS comments (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	content TEXT NOT NULL,
+	post_id BIGINT UNSIGNED NOT NULL,
+	user_id BIGINT UNSIGNED NOT NULL,
+	created_at DATETIME(3) NULL,
+	updated_at DATETIME(3) NULL,
+	PRIMARY KEY (id),
+	INDEX idx_comments_post_id (post_id),
+	INDEX idx_comments_user_id (user_id),
+	CONSTRAINT fk_posts_comments FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE,
+	CONSTRAINT fk_comments_user FOREIGN KEY (user_id) REFERENCES users (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,3 @@
+DROP INDEX idx_transactions_idempotency_key ON transactions;
+
+ALTER TABLE transactions DROP COLUMN idempotency_key;
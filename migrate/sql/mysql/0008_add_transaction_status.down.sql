@@ -0,0 +1,8 @@
+DROP INDEX idx_transactions_reversal_of_id ON transactions;
+
+ALTER TABLE transactions
+	DROP COLUMN reversal_of_id,
+	DROP COLUMN completed_at,
+	DROP COLUMN created_at,
+	DROP COLUMN reason,
+	DROP COLUMN status;
@@ -0,0 +1,11 @@
+ALTER TABLE postings DROP COLUMN currency;
+
+ALTER TABLE transactions
+	DROP COLUMN exchange_rate,
+	DROP COLUMN target_currency,
+	DROP COLUMN target_amount,
+	DROP COLUMN currency;
+
+DROP TABLE IF EXISTS exchange_rates;
+
+ALTER TABLE accounts DROP COLUMN currency;
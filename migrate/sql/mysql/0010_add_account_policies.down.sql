@@ -0,0 +1,5 @@
+DROP INDEX idx_transactions_from_account_completed_at ON transactions;
+
+DROP TABLE IF EXISTS account_policies;
+
+ALTER TABLE accounts DROP COLUMN type;
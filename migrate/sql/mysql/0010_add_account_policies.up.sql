@@ -0,0 +1,21 @@
+-- 账户类型，转账限制可以按账户类型配置；已有账户都是普通账户
+ALTER TABLE accounts ADD COLUMN type VARCHAR(20) NOT NULL DEFAULT 'standard';
+
+-- 转账限制：account_id 和 account_type 二选一，账户的设置优先于账户类型的设置，NULL 表示不限制
+-- 金额以账户币种计
+CREATE TABLE IF NOT EXISTS account_policies (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	account_id BIGINT UNSIGNED NULL,
+	account_type VARCHAR(20) NULL,
+	max_per_transaction DECIMAL(12, 2) NULL,
+	daily_limit DECIMAL(12, 2) NULL,
+	overdraft_limit DECIMAL(12, 2) NULL,
+	minimum_balance DECIMAL(12, 2) NULL,
+	updated_at DATETIME(3) NULL,
+	PRIMARY KEY (id),
+	UNIQUE INDEX idx_account_policies_account_id (account_id),
+	UNIQUE INDEX idx_account_policies_account_type (account_type)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- 统计当日转出金额
+CREATE INDEX idx_transactions_from_account_completed_at ON transactions (from_account_id, completed_at);
@@ -0,0 +1,15 @@
+-- 账户状态：active（正常）、frozen（冻结，不能转出）、closed（已销户，不能转入或转出）
+ALTER TABLE accounts ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'active';
+
+-- 账户状态变更记录，只追加不修改
+CREATE TABLE IF NOT EXISTS account_status_changes (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	account_id BIGINT UNSIGNED NOT NULL,
+	from_status VARCHAR(20) NOT NULL,
+	to_status VARCHAR(20) NOT NULL,
+	reason VARCHAR(255) NOT NULL DEFAULT '',
+	actor VARCHAR(64) NOT NULL DEFAULT '',
+	created_at DATETIME(3) NULL,
+	PRIMARY KEY (id),
+	INDEX idx_account_status_changes_account_id (account_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,8 @@
+DROP INDEX idx_transactions_created_at ON transactions;
+DROP INDEX idx_transactions_channel_created_at ON transactions;
+DROP INDEX idx_transactions_initiator_created_at ON transactions;
+
+ALTER TABLE transactions
+	DROP COLUMN channel,
+	DROP COLUMN initiator_id,
+	DROP COLUMN memo;
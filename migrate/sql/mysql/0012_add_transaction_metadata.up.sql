@@ -0,0 +1,10 @@
+-- 转账的备注、发起人和渠道（api、batch、admin）；已有的交易记录都是通过 API 发起的
+ALTER TABLE transactions
+	ADD COLUMN memo VARCHAR(255) NOT NULL DEFAULT '',
+	ADD COLUMN initiator_id VARCHAR(64) NOT NULL DEFAULT '',
+	ADD COLUMN channel VARCHAR(20) NOT NULL DEFAULT 'api';
+
+-- 按发起人、渠道或时间范围查询转账
+CREATE INDEX idx_transactions_initiator_created_at ON transactions (initiator_id, created_at);
+CREATE INDEX idx_transactions_channel_created_at ON transactions (channel, created_at);
+CREATE INDEX idx_transactions_created_at ON transactions (created_at);
@@ -0,0 +1,32 @@
+-- 定时转账（长期指令）：schedule 为 cron 表达式或 @every 间隔，next_run_at 为下一期的计划执行时间（UTC）
+-- 执行器按 status 和 next_run_at 查找到期的定时转账
+-- status：active（生效）、ended（已过结束时间）、cancelled（已取消）
+CREATE TABLE IF NOT EXISTS scheduled_transfers (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	from_account_id BIGINT UNSIGNED NOT NULL,
+	to_account_id BIGINT UNSIGNED NOT NULL,
+	amount DECIMAL(10, 2) NOT NULL,
+	memo VARCHAR(255) NOT NULL DEFAULT '',
+	initiator_id VARCHAR(64) NOT NULL DEFAULT '',
+	schedule VARCHAR(100) NOT NULL,
+	next_run_at DATETIME(3) NOT NULL,
+	end_at DATETIME(3) NULL,
+	status VARCHAR(20) NOT NULL DEFAULT 'active',
+	created_at DATETIME(3) NULL,
+	updated_at DATETIME(3) NULL,
+	PRIMARY KEY (id),
+	INDEX idx_scheduled_transfers_status_next_run_at (status, next_run_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- 每一期的执行结果；同一个定时转账的同一期只有一条记录，重启后也不会重复执行
+CREATE TABLE IF NOT EXISTS scheduled_transfer_runs (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	scheduled_transfer_id BIGINT UNSIGNED NOT NULL,
+	scheduled_at DATETIME(3) NOT NULL,
+	transaction_id BIGINT UNSIGNED NULL,
+	status VARCHAR(20) NOT NULL,
+	error VARCHAR(255) NOT NULL DEFAULT '',
+	created_at DATETIME(3) NULL,
+	PRIMARY KEY (id),
+	UNIQUE INDEX idx_scheduled_transfer_runs_period (scheduled_transfer_id, scheduled_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,2 @@
+DROP INDEX idx_transactions_to_account_created_at ON transactions;
+DROP INDEX idx_transactions_from_account_created_at ON transactions;
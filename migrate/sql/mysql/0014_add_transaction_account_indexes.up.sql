@@ -0,0 +1,4 @@
+-- 按账户查询转账：from_account_id = ? OR to_account_id = ? 的两个条件各有一个索引，
+-- MySQL 用 index merge 合并两个索引的结果，不需要全表扫描
+CREATE INDEX idx_transactions_from_account_created_at ON transactions (from_account_id, created_at);
+CREATE INDEX idx_transactions_to_account_created_at ON transactions (to_account_id, created_at);
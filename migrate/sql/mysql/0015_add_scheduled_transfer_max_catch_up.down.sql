@@ -0,0 +1 @@
+ALTER TABLE scheduled_transfers DROP COLUMN max_catch_up;
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS students (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name VARCHAR(100),
+	age INTEGER,
+	grade VARCHAR(50)
+);
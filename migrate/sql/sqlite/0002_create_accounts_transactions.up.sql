@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS accounts (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	balance DECIMAL(10, 2)
+);
+
+CREATE TABLE IF NOT EXISTS transactions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	from_account_id INTEGER,
+	to_account_id INTEGER,
+	amount DECIMAL(10, 2)
+);
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS books (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	title VARCHAR(200) NOT NULL,
+	author VARCHAR(100) NOT NULL,
+	price DECIMAL(10, 2) NOT NULL
+);
@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS users (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	username VARCHAR(50) NOT NULL,
+	email VARCHAR(100) NOT NULL,
+	password VARCHAR(255) NOT NULL,
+	nickname VARCHAR(50),
+	post_count INTEGER DEFAULT 0,
+	created_at DATETIME,
+	updated_at DATETIME
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users (username);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+
+CREATE TABLE IF NOT EXISTS posts (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	title VARCHAR(200) NOT NULL,
+	content TEXT NOT NULL,
+	user_id INTEGER NOT NULL,
+	view_count INTEGER DEFAULT 0,
+	comment_status VARCHAR(20) DEFAULT '有评论',
+	created_at DATETIME,
+	updated_at DATETIME,
+	CONSTRAINT fk_users_posts FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_posts_title ON posts (title);
+CREATE INDEX IF NOT EXISTS idx_posts_user_id ON posts (user_id);
+
+CREATE TABLE IF NOT EXISTS comments (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	content TEXT NOT NULL,
+	post_id INTEGER NOT NULL,
+	user_id INTEGER NOT NULL,
+	created_at DATETIME,
+	updated_at DATETIME,
+	CONSTRAINT fk_posts_comments FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE,
+	CONSTRAINT fk_comments_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_comments_post_id ON comments (post_id);
+CREATE INDEX IF NOT EXISTS idx_comments_user_id ON comments (user_id);
@@ -0,0 +1,4 @@
+-- 客户端提供的幂等键，同一个键只能对应一笔转账；NULL 表示不做幂等检查
+ALTER TABLE transactions ADD COLUMN idempotency_key VARCHAR(64) NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_idempotency_key ON transactions (idempotency_key);
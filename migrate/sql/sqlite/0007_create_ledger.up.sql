@@ -0,0 +1,34 @@
+-- 复式记账：每笔资金变动记一张凭证（journal_entries），凭证下的分录（postings）金额之和为零
+-- 分录金额为正表示账户余额增加，为负表示减少；account_id 为 0 表示系统外部（开户存入等）
+CREATE TABLE IF NOT EXISTS journal_entries (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	transaction_id INTEGER,
+	description VARCHAR(200) NOT NULL,
+	created_at DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_journal_entries_transaction_id ON journal_entries (transaction_id);
+
+CREATE TABLE IF NOT EXISTS postings (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	journal_entry_id INTEGER NOT NULL,
+	account_id INTEGER NOT NULL,
+	amount DECIMAL(12, 2) NOT NULL,
+	CONSTRAINT fk_journal_entries_postings FOREIGN KEY (journal_entry_id) REFERENCES journal_entries (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_postings_journal_entry_id ON postings (journal_entry_id);
+CREATE INDEX IF NOT EXISTS idx_postings_account_id ON postings (account_id);
+
+-- 已有账户的余额记为一张期初凭证：各账户贷记当前余额，外部账户借记合计
+INSERT INTO journal_entries (description, created_at)
+SELECT '期初余额', CURRENT_TIMESTAMP
+WHERE EXISTS (SELECT 1 FROM accounts WHERE balance <> 0);
+
+INSERT INTO postings (journal_entry_id, account_id, amount)
+SELECT e.id, a.id, a.balance
+FROM journal_entries e, accounts a
+WHERE e.description = '期初余额' AND a.balance <> 0;
+
+INSERT INTO postings (journal_entry_id, account_id, amount)
+SELECT e.id, 0, -(SELECT SUM(balance) FROM accounts)
+FROM journal_entries e
+WHERE e.description = '期初余额' AND (SELECT SUM(balance) FROM accounts) <> 0;
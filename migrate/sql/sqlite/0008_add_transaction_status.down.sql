@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_transactions_reversal_of_id;
+
+ALTER TABLE transactions DROP COLUMN reversal_of_id;
+ALTER TABLE transactions DROP COLUMN completed_at;
+ALTER TABLE transactions DROP COLUMN created_at;
+ALTER TABLE transactions DROP COLUMN reason;
+ALTER TABLE transactions DROP COLUMN status;
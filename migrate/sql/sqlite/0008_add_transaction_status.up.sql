@@ -0,0 +1,10 @@
+-- 转账状态：pending（处理中）、completed（已完成）、failed（失败）、reversed（已冲正）
+-- 已有的交易记录都是成功的转账，状态为 completed
+ALTER TABLE transactions ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'completed';
+ALTER TABLE transactions ADD COLUMN reason VARCHAR(255) NULL;
+ALTER TABLE transactions ADD COLUMN created_at DATETIME NULL;
+ALTER TABLE transactions ADD COLUMN completed_at DATETIME NULL;
+
+-- 冲正交易指向被冲正的原交易，唯一索引保证一笔交易只能冲正一次
+ALTER TABLE transactions ADD COLUMN reversal_of_id INTEGER NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_reversal_of_id ON transactions (reversal_of_id);
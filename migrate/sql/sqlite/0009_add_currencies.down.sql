@@ -0,0 +1,10 @@
+ALTER TABLE postings DROP COLUMN currency;
+
+ALTER TABLE transactions DROP COLUMN exchange_rate;
+ALTER TABLE transactions DROP COLUMN target_currency;
+ALTER TABLE transactions DROP COLUMN target_amount;
+ALTER TABLE transactions DROP COLUMN currency;
+
+DROP TABLE IF EXISTS exchange_rates;
+
+ALTER TABLE accounts DROP COLUMN currency;
@@ -0,0 +1,24 @@
+-- 账户币种，已有账户都是人民币账户
+ALTER TABLE accounts ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'CNY';
+
+-- 汇率：1 单位 base_currency 兑换 rate 单位 quote_currency，从 effective_at 起生效，直到同一货币对的下一条汇率生效
+CREATE TABLE IF NOT EXISTS exchange_rates (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	base_currency CHAR(3) NOT NULL,
+	quote_currency CHAR(3) NOT NULL,
+	rate DECIMAL(18, 6) NOT NULL,
+	effective_at DATETIME NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exchange_rates_pair_effective_at ON exchange_rates (base_currency, quote_currency, effective_at);
+
+-- 交易记录转出金额（amount）的币种、转入金额及其币种和使用的汇率；同币种转账没有汇率
+-- 转出账户不存在的失败交易币种为空；已有的交易都是人民币转账
+ALTER TABLE transactions ADD COLUMN currency CHAR(3) NOT NULL DEFAULT '';
+ALTER TABLE transactions ADD COLUMN target_amount DECIMAL(10, 2) NULL;
+ALTER TABLE transactions ADD COLUMN target_currency CHAR(3) NOT NULL DEFAULT '';
+ALTER TABLE transactions ADD COLUMN exchange_rate DECIMAL(18, 6) NULL;
+UPDATE transactions SET currency = 'CNY', target_currency = 'CNY';
+UPDATE transactions SET target_amount = amount WHERE status <> 'failed';
+
+-- 分录的币种，同一张凭证内每个币种分别借贷平衡
+ALTER TABLE postings ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'CNY';
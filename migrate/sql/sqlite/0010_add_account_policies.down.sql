@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_transactions_from_account_completed_at;
+
+DROP TABLE IF EXISTS account_policies;
+
+ALTER TABLE accounts DROP COLUMN type;
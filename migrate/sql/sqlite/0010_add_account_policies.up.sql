@@ -0,0 +1,20 @@
+-- 账户类型，转账限制可以按账户类型配置；已有账户都是普通账户
+ALTER TABLE accounts ADD COLUMN type VARCHAR(20) NOT NULL DEFAULT 'standard';
+
+-- 转账限制：account_id 和 account_type 二选一，账户的设置优先于账户类型的设置，NULL 表示不限制
+-- 金额以账户币种计
+CREATE TABLE IF NOT EXISTS account_policies (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	account_id INTEGER NULL,
+	account_type VARCHAR(20) NULL,
+	max_per_transaction DECIMAL(12, 2) NULL,
+	daily_limit DECIMAL(12, 2) NULL,
+	overdraft_limit DECIMAL(12, 2) NULL,
+	minimum_balance DECIMAL(12, 2) NULL,
+	updated_at DATETIME NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_account_policies_account_id ON account_policies (account_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_account_policies_account_type ON account_policies (account_type);
+
+-- 统计当日转出金额
+CREATE INDEX IF NOT EXISTS idx_transactions_from_account_completed_at ON transactions (from_account_id, completed_at);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS account_status_changes;
+
+ALTER TABLE accounts DROP COLUMN status;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_transactions_created_at;
+DROP INDEX IF EXISTS idx_transactions_channel_created_at;
+DROP INDEX IF EXISTS idx_transactions_initiator_created_at;
+
+ALTER TABLE transactions DROP COLUMN channel;
+ALTER TABLE transactions DROP COLUMN initiator_id;
+ALTER TABLE transactions DROP COLUMN memo;
@@ -0,0 +1,30 @@
+-- 定时转账（长期指令）：schedule 为 cron 表达式或 @every 间隔，next_run_at 为下一期的计划执行时间（UTC）
+-- status：active（生效）、ended（已过结束时间）、cancelled（已取消）
+CREATE TABLE IF NOT EXISTS scheduled_transfers (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	from_account_id INTEGER NOT NULL,
+	to_account_id INTEGER NOT NULL,
+	amount DECIMAL(10, 2) NOT NULL,
+	memo VARCHAR(255) NOT NULL DEFAULT '',
+	initiator_id VARCHAR(64) NOT NULL DEFAULT '',
+	schedule VARCHAR(100) NOT NULL,
+	next_run_at DATETIME NOT NULL,
+	end_at DATETIME NULL,
+	status VARCHAR(20) NOT NULL DEFAULT 'active',
+	created_at DATETIME NULL,
+	updated_at DATETIME NULL
+);
+-- 执行器按状态和下一期时间查找到期的定时转账
+CREATE INDEX IF NOT EXISTS idx_scheduled_transfers_status_next_run_at ON scheduled_transfers (status, next_run_at);
+
+-- 每一期的执行结果；同一个定时转账的同一期只有一条记录，重启后也不会重复执行
+CREATE TABLE IF NOT EXISTS scheduled_transfer_runs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	scheduled_transfer_id INTEGER NOT NULL,
+	scheduled_at DATETIME NOT NULL,
+	transaction_id INTEGER NULL,
+	status VARCHAR(20) NOT NULL,
+	error VARCHAR(255) NOT NULL DEFAULT '',
+	created_at DATETIME NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_scheduled_transfer_runs_period ON scheduled_transfer_runs (scheduled_transfer_id, scheduled_at);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_transactions_to_account_created_at;
+DROP INDEX IF EXISTS idx_transactions_from_account_created_at;
@@ -0,0 +1,2 @@
+-- 停机后最多补执行的期次：0 表示逐期补执行全部错过的期次，n 表示只执行最近的 n 期，更早的期次记为一条 skipped 执行记录
+ALTER TABLE scheduled_transfers ADD COLUMN max_catch_up INT NOT NULL DEFAULT 0;
@@ -0,0 +1,52 @@
+package money
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Currency ISO 4217 货币代码
+type Currency string
+
+// 支持的货币，金额都保留两位小数
+const (
+	CNY Currency = "CNY"
+	USD Currency = "USD"
+	EUR Currency = "EUR"
+)
+
+// Currencies 支持的全部货币
+var Currencies = []Currency{CNY, USD, EUR}
+
+// ParseCurrency 解析货币代码，不区分大小写
+func ParseCurrency(s string) (Currency, error) {
+	c := Currency(strings.ToUpper(strings.TrimSpace(s)))
+	if !c.Valid() {
+		return "", fmt.Errorf("不支持的货币 %q（可选: %s、%s、%s）", s, CNY, USD, EUR)
+	}
+	return c, nil
+}
+
+// Valid 是否为支持的货币
+func (c Currency) Valid() bool {
+	for _, v := range Currencies {
+		if c == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Set 实现 flag.Value，用于命令行参数
+func (c *Currency) Set(s string) error {
+	v, err := ParseCurrency(s)
+	if err != nil {
+		return err
+	}
+	*c = v
+	return nil
+}
+
+func (c Currency) String() string {
+	return string(c)
+}
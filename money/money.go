@@ -0,0 +1,268 @@
+// Package money 提供精确的金额类型，避免 float64 在累加和比较时的二进制舍入误差
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money 金额，以分（最小货币单位）为单位的整数
+// 对应数据库中的 DECIMAL(p, 2) 列：写入时转换为 "123.45" 形式的字符串，读取时按十进制精确解析。
+type Money int64
+
+// Scale 小数位数，1 元 = 100 分
+const (
+	Scale        = 2
+	centsPerUnit = 100
+)
+
+// ErrPrecision 金额的小数位数超过 Scale，需要使用 ParseRound 指定舍入方式
+var ErrPrecision = errors.New("金额最多保留两位小数")
+
+// ErrOverflow 金额超出 int64 能表示的范围
+var ErrOverflow = errors.New("金额超出范围")
+
+// RoundingMode 舍入方式，作用于金额的绝对值，结果再加上符号
+type RoundingMode int
+
+const (
+	// RoundHalfEven 银行家舍入：舍弃部分恰好为一半时舍入到偶数分，其他情况四舍五入
+	RoundHalfEven RoundingMode = iota
+	// RoundHalfUp 四舍五入：舍弃部分恰好为一半时远离零
+	RoundHalfUp
+	// RoundDown 截断：直接舍弃多余的小数位（向零舍入）
+	RoundDown
+)
+
+// FromCents 返回 cents 分
+func FromCents(cents int64) Money {
+	return Money(cents)
+}
+
+// FromUnits 返回 units 元
+func FromUnits(units int64) Money {
+	return Money(units * centsPerUnit)
+}
+
+// FromFloat 把浮点数按 mode 舍入到分
+// 浮点数先转换为最短的十进制表示（例如 0.1+0.2 为 0.30000000000000004），再按十进制舍入。
+func FromFloat(f float64, mode RoundingMode) (Money, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("无效的金额 %v", f)
+	}
+	return ParseRound(strconv.FormatFloat(f, 'f', -1, 64), mode)
+}
+
+// Parse 解析 "123.45"、"-0.5"、"100" 形式的金额，小数超过两位时返回 ErrPrecision
+func Parse(s string) (Money, error) {
+	return parse(s, RoundDown, true)
+}
+
+// MustParse 与 Parse 相同，解析失败时 panic，用于常量
+func MustParse(s string) Money {
+	m, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// ParseRound 解析金额，超过两位的小数按 mode 舍入
+func ParseRound(s string, mode RoundingMode) (Money, error) {
+	return parse(s, mode, false)
+}
+
+func parse(s string, mode RoundingMode, strict bool) (Money, error) {
+	v, err := parseFixed(s, Scale, mode, strict)
+	if errors.Is(err, ErrPrecision) {
+		return 0, fmt.Errorf("%w: %q", ErrPrecision, s)
+	}
+	return Money(v), err
+}
+
+// parseFixed 把十进制字符串解析为保留 scale 位小数的定点数（按 10^scale 放大后的整数）
+// 多余的小数位在 strict 时返回 ErrPrecision，否则按 mode 舍入。
+func parseFixed(s string, scale int, mode RoundingMode, strict bool) (int64, error) {
+	str := strings.TrimSpace(s)
+	neg := false
+	switch {
+	case strings.HasPrefix(str, "-"):
+		neg, str = true, str[1:]
+	case strings.HasPrefix(str, "+"):
+		str = str[1:]
+	}
+	intPart, fracPart, _ := strings.Cut(str, ".")
+	if intPart == "" && fracPart == "" || !isDigits(intPart) || !isDigits(fracPart) {
+		return 0, fmt.Errorf("无效的金额 %q", s)
+	}
+
+	factor := pow10(scale)
+	var units int64
+	if intPart != "" {
+		v, err := strconv.ParseInt(intPart, 10, 64)
+		if err != nil || v > (math.MaxInt64-factor+1)/factor {
+			return 0, fmt.Errorf("%w: %q", ErrOverflow, s)
+		}
+		units = v
+	}
+
+	// 前 scale 位小数保留，其余部分决定舍入
+	digits := fracPart + strings.Repeat("0", max(0, scale-len(fracPart)))
+	var frac int64
+	if scale > 0 {
+		frac, _ = strconv.ParseInt(digits[:scale], 10, 64)
+	}
+	rest := strings.TrimRight(digits[scale:], "0")
+	if rest != "" {
+		if strict {
+			return 0, ErrPrecision
+		}
+		frac += roundUp(mode, rest, frac)
+	}
+
+	v := units*factor + frac
+	if neg {
+		v = -v
+	}
+	return v, nil
+}
+
+// roundUp 根据舍弃的小数位 rest（不含末尾的 0）判断保留的最后一位是否进一
+func roundUp(mode RoundingMode, rest string, kept int64) int64 {
+	switch mode {
+	case RoundDown:
+		return 0
+	case RoundHalfUp:
+		if rest[0] >= '5' {
+			return 1
+		}
+	case RoundHalfEven:
+		switch {
+		case rest[0] > '5', rest[0] == '5' && len(rest) > 1:
+			return 1
+		case rest == "5" && kept%2 == 1:
+			return 1
+		}
+	}
+	return 0
+}
+
+func pow10(n int) int64 {
+	v := int64(1)
+	for i := 0; i < n; i++ {
+		v *= 10
+	}
+	return v
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Cents 返回以分为单位的整数
+func (m Money) Cents() int64 {
+	return int64(m)
+}
+
+// IsPositive 金额是否大于零
+func (m Money) IsPositive() bool {
+	return m > 0
+}
+
+// String 返回保留两位小数的金额，例如 "1234.50"、"-0.05"
+func (m Money) String() string {
+	sign := ""
+	abs := uint64(m)
+	if m < 0 {
+		sign = "-"
+		abs = uint64(-m)
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, abs/centsPerUnit, abs%centsPerUnit)
+}
+
+// Format 返回带千位分隔符和货币代码的金额，例如 "1,234.50 CNY"
+// Money 本身不记录币种，币种由调用方按账户或交易提供。
+func (m Money) Format(c Currency) string {
+	s := m.String()
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	var b strings.Builder
+	for i, c := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	return sign + b.String() + "." + fracPart + " " + string(c)
+}
+
+// MarshalJSON 输出为 JSON 数字，例如 123.45，保留两位小数且没有浮点误差
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON 接受 JSON 数字或字符串，小数超过两位时报错
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	v, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// Set 实现 flag.Value，用于命令行参数
+func (m *Money) Set(s string) error {
+	v, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// Value 实现 driver.Valuer，以十进制字符串写入 DECIMAL 列
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan 实现 sql.Scanner
+// MySQL 的 DECIMAL 返回 []byte；SQLite 按数值亲和性存储，返回 int64 或 float64。
+func (m *Money) Scan(src any) error {
+	var (
+		v   Money
+		err error
+	)
+	switch x := src.(type) {
+	case nil:
+		v = 0
+	case int64:
+		v = FromUnits(x)
+	case float64:
+		v, err = FromFloat(x, RoundHalfEven)
+	case []byte:
+		v, err = ParseRound(string(x), RoundHalfEven)
+	case string:
+		v, err = ParseRound(x, RoundHalfEven)
+	default:
+		return fmt.Errorf("无法把 %T 转换为金额", src)
+	}
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
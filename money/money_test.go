@@ -0,0 +1,210 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Money
+		wantErr error
+	}{
+		{"123.45", 12345, nil},
+		{"100", 10000, nil},
+		{"0.5", 50, nil},
+		{".05", 5, nil},
+		{"-0.05", -5, nil},
+		{"+7.10", 710, nil},
+		{"1.230", 123, nil},
+		{"1.234", 0, ErrPrecision},
+		{"99999999999999999999", 0, ErrOverflow},
+		{"", 0, nil},
+		{"1,000", 0, nil},
+		{"abc", 0, nil},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if tt.want == 0 {
+			if err == nil {
+				t.Errorf("Parse(%q) = %v, 期望报错", tt.in, got)
+			} else if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Parse(%q) err = %v, 期望 %v", tt.in, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Parse(%q) = %d, %v, 期望 %d", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseRound(t *testing.T) {
+	tests := []struct {
+		in   string
+		mode RoundingMode
+		want Money
+	}{
+		{"1.005", RoundHalfEven, 100},
+		{"1.015", RoundHalfEven, 102},
+		{"1.0051", RoundHalfEven, 101},
+		{"1.004", RoundHalfEven, 100},
+		{"1.005", RoundHalfUp, 101},
+		{"1.004", RoundHalfUp, 100},
+		{"-1.005", RoundHalfUp, -101},
+		{"1.009", RoundDown, 100},
+		{"-1.009", RoundDown, -100},
+	}
+	for _, tt := range tests {
+		got, err := ParseRound(tt.in, tt.mode)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseRound(%q, %d) = %d, %v, 期望 %d", tt.in, tt.mode, got, err, tt.want)
+		}
+	}
+}
+
+func TestFromFloat(t *testing.T) {
+	// 0.1 + 0.2 = 0.30000000000000004，舍入到分后精确为 0.30
+	got, err := FromFloat(0.1+0.2, RoundHalfEven)
+	if err != nil || got != 30 {
+		t.Errorf("FromFloat(0.1+0.2) = %d, %v, 期望 30", got, err)
+	}
+
+	// 累加 float64 会产生误差，累加 Money 不会
+	var f float64
+	var m Money
+	for i := 0; i < 10; i++ {
+		f += 0.1
+		m += MustParse("0.10")
+	}
+	if f == 1 {
+		t.Fatal("float64 累加 0.1 十次应当有误差")
+	}
+	if m != FromUnits(1) {
+		t.Errorf("Money 累加 0.10 十次 = %s, 期望 1.00", m)
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		m          Money
+		str, fmted string
+	}{
+		{0, "0.00", "0.00 CNY"},
+		{5, "0.05", "0.05 CNY"},
+		{-5, "-0.05", "-0.05 CNY"},
+		{123456789, "1234567.89", "1,234,567.89 CNY"},
+		{-100000, "-1000.00", "-1,000.00 CNY"},
+	}
+	for _, tt := range tests {
+		if got := tt.m.String(); got != tt.str {
+			t.Errorf("String(%d) = %s, 期望 %s", tt.m, got, tt.str)
+		}
+		if got := tt.m.Format(CNY); got != tt.fmted {
+			t.Errorf("Format(%d) = %s, 期望 %s", tt.m, got, tt.fmted)
+		}
+	}
+	if got := MustParse("1234.5").Format(USD); got != "1,234.50 USD" {
+		t.Errorf("Format(USD) = %s, 期望 1,234.50 USD", got)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	data, err := json.Marshal(struct{ Amount Money }{MustParse("10.50")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"Amount":10.50}` {
+		t.Errorf("Marshal = %s", data)
+	}
+
+	var v struct{ A, B Money }
+	if err := json.Unmarshal([]byte(`{"A": 1.5, "B": "2.25"}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A != 150 || v.B != 225 {
+		t.Errorf("Unmarshal = %+v", v)
+	}
+	if err := json.Unmarshal([]byte(`{"A": 1.555}`), &v); !errors.Is(err, ErrPrecision) {
+		t.Errorf("三位小数: err = %v, 期望 ErrPrecision", err)
+	}
+}
+
+func TestScanValue(t *testing.T) {
+	tests := []struct {
+		src  any
+		want Money
+	}{
+		{[]byte("500.00"), 50000},
+		{"12.30", 1230},
+		{int64(300), 30000},
+		{float64(123.45), 12345},
+		{nil, 0},
+	}
+	for _, tt := range tests {
+		var m Money
+		if err := m.Scan(tt.src); err != nil || m != tt.want {
+			t.Errorf("Scan(%v) = %d, %v, 期望 %d", tt.src, m, err, tt.want)
+		}
+	}
+	var m Money
+	if err := m.Scan(true); err == nil {
+		t.Error("Scan(bool) 应当报错")
+	}
+
+	v, err := MustParse("-3.07").Value()
+	if err != nil || v != "-3.07" {
+		t.Errorf("Value = %v, %v", v, err)
+	}
+}
+
+func TestParseCurrency(t *testing.T) {
+	if c, err := ParseCurrency(" usd "); err != nil || c != USD {
+		t.Errorf("ParseCurrency(usd) = %q, %v", c, err)
+	}
+	if _, err := ParseCurrency("JPY"); err == nil {
+		t.Error("不支持的货币应当报错")
+	}
+}
+
+func TestRate(t *testing.T) {
+	r := MustParseRate("0.138")
+	if r.String() != "0.138000" {
+		t.Errorf("String = %s", r)
+	}
+	for _, s := range []string{"0", "-1", "1.0000001", "abc"} {
+		if _, err := ParseRate(s); err == nil {
+			t.Errorf("ParseRate(%q) 应当报错", s)
+		}
+	}
+
+	tests := []struct {
+		rate string
+		m    Money
+		mode RoundingMode
+		want Money
+	}{
+		{"7.1", FromUnits(100), RoundHalfEven, FromUnits(710)},
+		{"0.138", FromUnits(100), RoundHalfEven, MustParse("13.80")},
+		// 0.05 × 0.5 = 0.025：银行家舍入到 0.02，四舍五入到 0.03
+		{"0.5", MustParse("0.05"), RoundHalfEven, MustParse("0.02")},
+		{"0.5", MustParse("0.05"), RoundHalfUp, MustParse("0.03")},
+		{"0.5", MustParse("0.07"), RoundHalfEven, MustParse("0.04")},
+		{"1.234567", MustParse("-10.00"), RoundDown, MustParse("-12.34")},
+	}
+	for _, tt := range tests {
+		got, err := MustParseRate(tt.rate).Convert(tt.m, tt.mode)
+		if err != nil || got != tt.want {
+			t.Errorf("%s × %s = %s, %v, 期望 %s", tt.m, tt.rate, got, err, tt.want)
+		}
+	}
+
+	var scanned Rate
+	for _, src := range []any{[]byte("7.123400"), 7.1234, "7.1234"} {
+		if err := scanned.Scan(src); err != nil || scanned != MustParseRate("7.1234") {
+			t.Errorf("Scan(%v) = %s, %v", src, scanned, err)
+		}
+	}
+}
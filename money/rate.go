@@ -0,0 +1,106 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// Rate 汇率，保留 RateScale 位小数的定点数：1 单位基础货币兑换 Rate 单位报价货币
+// 对应数据库中的 DECIMAL(p, 6) 列。
+type Rate int64
+
+// RateScale 汇率的小数位数
+const RateScale = 6
+
+var rateFactor = pow10(RateScale)
+
+// ParseRate 解析 "7.1234" 形式的汇率，汇率必须大于零，小数超过 RateScale 位时报错
+func ParseRate(s string) (Rate, error) {
+	v, err := parseFixed(s, RateScale, RoundDown, true)
+	if err != nil {
+		return 0, fmt.Errorf("无效的汇率 %q: %w", s, err)
+	}
+	if v <= 0 {
+		return 0, fmt.Errorf("汇率必须大于零: %q", s)
+	}
+	return Rate(v), nil
+}
+
+// MustParseRate 与 ParseRate 相同，解析失败时 panic，用于常量
+func MustParseRate(s string) Rate {
+	r, err := ParseRate(s)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Convert 按汇率换算金额，结果按 mode 舍入到分
+func (r Rate) Convert(m Money, mode RoundingMode) (Money, error) {
+	product := new(big.Int).Mul(big.NewInt(int64(m)), big.NewInt(int64(r)))
+	neg := product.Sign() < 0
+	product.Abs(product)
+
+	quo, rem := new(big.Int).QuoRem(product, big.NewInt(rateFactor), new(big.Int))
+	if rem.Sign() != 0 {
+		// 舍弃部分与一半比较：2*rem 与 rateFactor
+		half := new(big.Int).Lsh(rem, 1).Cmp(big.NewInt(rateFactor))
+		up := false
+		switch mode {
+		case RoundHalfUp:
+			up = half >= 0
+		case RoundHalfEven:
+			up = half > 0 || half == 0 && quo.Bit(0) == 1
+		}
+		if up {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+	if !quo.IsInt64() {
+		return 0, fmt.Errorf("%w: %s × %s", ErrOverflow, m, r)
+	}
+	v := Money(quo.Int64())
+	if neg {
+		v = -v
+	}
+	return v, nil
+}
+
+// String 返回保留 RateScale 位小数的汇率，例如 "7.123400"
+func (r Rate) String() string {
+	return fmt.Sprintf("%d.%0*d", int64(r)/rateFactor, RateScale, int64(r)%rateFactor)
+}
+
+// Value 实现 driver.Valuer，以十进制字符串写入 DECIMAL 列
+func (r Rate) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+// Scan 实现 sql.Scanner，与 Money.Scan 一样兼容 MySQL 和 SQLite 返回的类型
+func (r *Rate) Scan(src any) error {
+	var s string
+	switch x := src.(type) {
+	case int64:
+		s = strconv.FormatInt(x, 10)
+	case float64:
+		s = strconv.FormatFloat(x, 'f', -1, 64)
+	case []byte:
+		s = string(x)
+	case string:
+		s = x
+	default:
+		return fmt.Errorf("无法把 %T 转换为汇率", src)
+	}
+	v, err := parseFixed(s, RateScale, RoundHalfEven, false)
+	if err != nil {
+		return err
+	}
+	if v <= 0 {
+		return errors.New("汇率必须大于零")
+	}
+	*r = Rate(v)
+	return nil
+}
@@ -0,0 +1,245 @@
+package presenter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// 查询结果展示层
+// 查询函数只返回类型化的结果，由 Printer 按指定格式渲染：
+//   text  每条记录一行 "标签: 值"，嵌套的切片缩进展示
+//   json  每个结果输出一行 {"title": ..., "data": ...}
+//   table 以表格展示，只包含标量字段
+// 字段标签取自结构体的 label 标签，没有时使用字段名；json:"-" 的字段不展示。
+
+// Format 输出格式
+type Format string
+
+const (
+	FormatText  Format = "text"
+	FormatJSON  Format = "json"
+	FormatTable Format = "table"
+)
+
+// Formats 所有支持的输出格式
+var Formats = []Format{FormatText, FormatJSON, FormatTable}
+
+// ParseFormat 解析输出格式名称
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("不支持的输出格式 %q（可选: text、json、table）", s)
+}
+
+// Printer 按格式渲染查询结果
+type Printer struct {
+	format Format
+	w      io.Writer
+}
+
+// New 创建 Printer
+func New(format Format, w io.Writer) *Printer {
+	return &Printer{format: format, w: w}
+}
+
+type ctxKey struct{}
+
+// NewContext 返回携带 Printer 的 context
+func NewContext(ctx context.Context, p *Printer) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// FromContext 取出 context 中的 Printer，没有时返回输出到标准输出的 text 格式 Printer
+func FromContext(ctx context.Context) *Printer {
+	if p, ok := ctx.Value(ctxKey{}).(*Printer); ok {
+		return p
+	}
+	return New(FormatText, os.Stdout)
+}
+
+// Print 渲染一个结果，v 可以是结构体、结构体指针或结构体切片
+func (p *Printer) Print(title string, v any) error {
+	switch p.format {
+	case FormatJSON:
+		return json.NewEncoder(p.w).Encode(struct {
+			Title string `json:"title"`
+			Data  any    `json:"data"`
+		}{title, v})
+	case FormatTable:
+		fmt.Fprintf(p.w, "%s:\n", title)
+		return p.table(reflect.ValueOf(v))
+	default:
+		fmt.Fprintf(p.w, "%s:\n", title)
+		p.text(reflect.ValueOf(v), "")
+		return nil
+	}
+}
+
+// text 以 "标签: 值" 的形式逐行输出
+func (p *Printer) text(v reflect.Value, indent string) {
+	v = indirect(v)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 {
+			fmt.Fprintf(p.w, "%s(无)\n", indent)
+		}
+		for i := 0; i < v.Len(); i++ {
+			p.text(v.Index(i), indent)
+		}
+	case reflect.Struct:
+		var scalars []string
+		var nested []reflect.Value
+		var nestedLabels []string
+		for _, f := range fields(v.Type()) {
+			fv := v.FieldByIndex(f.index)
+			if f.nested {
+				if isEmpty(fv) {
+					continue
+				}
+				nested = append(nested, fv)
+				nestedLabels = append(nestedLabels, f.label)
+				continue
+			}
+			scalars = append(scalars, f.label+": "+formatValue(fv))
+		}
+		fmt.Fprintf(p.w, "%s%s\n", indent, strings.Join(scalars, ", "))
+		for i, fv := range nested {
+			fmt.Fprintf(p.w, "%s  %s:\n", indent, nestedLabels[i])
+			p.text(fv, indent+"    ")
+		}
+	case reflect.Invalid:
+		fmt.Fprintf(p.w, "%s(无)\n", indent)
+	default:
+		fmt.Fprintf(p.w, "%s%s\n", indent, formatValue(v))
+	}
+}
+
+// table 以表格输出，嵌套的结构体和切片不展示
+func (p *Printer) table(v reflect.Value) error {
+	v = indirect(v)
+	var rows []reflect.Value
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			rows = append(rows, indirect(v.Index(i)))
+		}
+	case reflect.Struct:
+		rows = append(rows, v)
+	default:
+		_, err := fmt.Fprintln(p.w, formatValue(v))
+		return err
+	}
+	if len(rows) == 0 || rows[0].Kind() != reflect.Struct {
+		_, err := fmt.Fprintln(p.w, "(无)")
+		return err
+	}
+
+	var cols []field
+	for _, f := range fields(rows[0].Type()) {
+		if !f.nested {
+			cols = append(cols, f)
+		}
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	labels := make([]string, len(cols))
+	for i, c := range cols {
+		labels[i] = c.label
+	}
+	fmt.Fprintln(tw, strings.Join(labels, "\t"))
+	for _, row := range rows {
+		cells := make([]string, len(cols))
+		for i, c := range cols {
+			cells[i] = formatValue(row.FieldByIndex(c.index))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+type field struct {
+	label  string
+	index  []int
+	nested bool // 结构体或切片字段（time.Time 除外）
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func fields(t reflect.Type) []field {
+	var list []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() || sf.Tag.Get("json") == "-" {
+			continue
+		}
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			for _, f := range fields(sf.Type) {
+				f.index = append([]int{i}, f.index...)
+				list = append(list, f)
+			}
+			continue
+		}
+		label := sf.Tag.Get("label")
+		if label == "" {
+			label = sf.Name
+		}
+		ft := sf.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		nested := ft != timeType && (ft.Kind() == reflect.Struct || ft.Kind() == reflect.Slice)
+		list = append(list, field{label: label, index: []int{i}, nested: nested})
+	}
+	return list
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func formatValue(v reflect.Value) string {
+	v = indirect(v)
+	if !v.IsValid() {
+		return ""
+	}
+	if v.Type() == timeType {
+		return v.Interface().(time.Time).Format("2006-01-02 15:04:05")
+	}
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return fmt.Sprintf("%.2f", v.Float())
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// isEmpty 判断嵌套字段是否为空：nil、零值结构体或空切片
+func isEmpty(v reflect.Value) bool {
+	v = indirect(v)
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Slice, reflect.Array:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
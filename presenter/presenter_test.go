@@ -0,0 +1,78 @@
+package presenter
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type item struct {
+	ID       int     `json:"id" label:"编号"`
+	Name     string  `json:"name" label:"名称"`
+	Price    float64 `json:"price"`
+	Secret   string  `json:"-"`
+	Children []item  `json:"children,omitempty" label:"子项"`
+}
+
+var items = []item{
+	{ID: 1, Name: "a", Price: 1.5, Secret: "x", Children: []item{{ID: 3, Name: "c"}}},
+	{ID: 2, Name: "b", Price: 20},
+}
+
+func render(t *testing.T, f Format, v any) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := New(f, &buf).Print("标题", v); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestText(t *testing.T) {
+	want := `标题:
+编号: 1, 名称: a, Price: 1.50
+  子项:
+    编号: 3, 名称: c, Price: 0.00
+编号: 2, 名称: b, Price: 20.00
+`
+	if got := render(t, FormatText, items); got != want {
+		t.Errorf("text 输出:\n%s\n期望:\n%s", got, want)
+	}
+}
+
+func TestTable(t *testing.T) {
+	got := render(t, FormatTable, items)
+	lines := strings.Split(strings.TrimSpace(got), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("table 输出行数 = %d:\n%s", len(lines), got)
+	}
+	if fields := strings.Fields(lines[1]); strings.Join(fields, " ") != "编号 名称 Price" {
+		t.Errorf("表头 = %q", lines[1])
+	}
+	if strings.Contains(got, "x") {
+		t.Errorf("json:\"-\" 字段不应输出:\n%s", got)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var doc struct {
+		Title string `json:"title"`
+		Data  []item `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(render(t, FormatJSON, items)), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Title != "标题" || len(doc.Data) != 2 || doc.Data[0].Children[0].Name != "c" {
+		t.Errorf("json 输出 = %+v", doc)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("不支持的格式应当报错")
+	}
+	if f, err := ParseFormat("table"); err != nil || f != FormatTable {
+		t.Errorf("ParseFormat(table) = %v, %v", f, err)
+	}
+}
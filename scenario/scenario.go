@@ -0,0 +1,119 @@
+package scenario
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// Scenario 可执行的示例
+// 各示例包在 init 中调用 Register 注册自己，调用方通过注册表发现并执行示例。
+type Scenario interface {
+	// Name 示例名称，用于命令行 run <name>
+	Name() string
+	// Description 示例说明
+	Description() string
+	// Setup 准备表结构和测试数据
+	Setup(ctx context.Context, db *gorm.DB) error
+	// Run 执行示例
+	Run(ctx context.Context, db *gorm.DB) error
+	// Teardown 清理 Setup 和 Run 留下的资源
+	Teardown(ctx context.Context, db *gorm.DB) error
+}
+
+// FlagBinder 可选接口，示例通过它注册自己的命令行参数
+type FlagBinder interface {
+	BindFlags(fs *flag.FlagSet)
+}
+
+var (
+	mu       sync.RWMutex
+	registry = make(map[string]Scenario)
+)
+
+// Register 注册示例，名称重复时 panic
+func Register(s Scenario) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	name := s.Name()
+	if name == "" {
+		panic("scenario: 示例名称不能为空")
+	}
+	if _, dup := registry[name]; dup {
+		panic(fmt.Sprintf("scenario: 示例 %q 重复注册", name))
+	}
+	registry[name] = s
+}
+
+// Lookup 按名称查找示例
+func Lookup(name string) (Scenario, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	s, ok := registry[name]
+	return s, ok
+}
+
+// All 返回所有已注册的示例，按名称排序
+func All() []Scenario {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	list := make([]Scenario, 0, len(registry))
+	for _, s := range registry {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	return list
+}
+
+// Names 返回所有已注册示例的名称，按名称排序
+func Names() []string {
+	all := All()
+	names := make([]string, len(all))
+	for i, s := range all {
+		names[i] = s.Name()
+	}
+	return names
+}
+
+// Execute 依次执行示例的 Setup、Run 和 Teardown
+// Setup 成功后无论 Run 是否失败都会执行 Teardown，返回的错误包含所有阶段的错误。
+// 传给示例的 ctx 和 db 都携带示例名称，SQL 日志据此标记语句所属的示例。
+func Execute(ctx context.Context, db *gorm.DB, s Scenario) error {
+	ctx = NewContext(ctx, s.Name())
+	if db != nil {
+		db = db.WithContext(ctx)
+	}
+
+	if err := s.Setup(ctx, db); err != nil {
+		return fmt.Errorf("%s: setup: %w", s.Name(), err)
+	}
+
+	var errs []error
+	if err := s.Run(ctx, db); err != nil {
+		errs = append(errs, fmt.Errorf("%s: run: %w", s.Name(), err))
+	}
+	if err := s.Teardown(ctx, db); err != nil {
+		errs = append(errs, fmt.Errorf("%s: teardown: %w", s.Name(), err))
+	}
+	return errors.Join(errs...)
+}
+
+type ctxKey struct{}
+
+// NewContext 返回携带示例名称的 context
+func NewContext(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, name)
+}
+
+// NameFromContext 返回 context 中正在执行的示例名称，不在示例中时返回空字符串
+func NameFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(ctxKey{}).(string)
+	return name
+}
@@ -0,0 +1,89 @@
+package scenario
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type fakeScenario struct {
+	name     string
+	setupErr error
+	runErr   error
+	calls    []string
+	ctxName  string
+}
+
+func (f *fakeScenario) Name() string        { return f.name }
+func (f *fakeScenario) Description() string { return "fake" }
+
+func (f *fakeScenario) Setup(context.Context, *gorm.DB) error {
+	f.calls = append(f.calls, "setup")
+	return f.setupErr
+}
+
+func (f *fakeScenario) Run(ctx context.Context, _ *gorm.DB) error {
+	f.calls = append(f.calls, "run")
+	f.ctxName = NameFromContext(ctx)
+	return f.runErr
+}
+
+func (f *fakeScenario) Teardown(context.Context, *gorm.DB) error {
+	f.calls = append(f.calls, "teardown")
+	return nil
+}
+
+func TestExecute(t *testing.T) {
+	errRun := errors.New("run failed")
+	tests := []struct {
+		name      string
+		s         *fakeScenario
+		wantCalls []string
+		wantErr   error
+	}{
+		{"成功", &fakeScenario{name: "ok"}, []string{"setup", "run", "teardown"}, nil},
+		{"Run 失败仍然 Teardown", &fakeScenario{name: "run", runErr: errRun}, []string{"setup", "run", "teardown"}, errRun},
+		{"Setup 失败不执行 Run", &fakeScenario{name: "setup", setupErr: errRun}, []string{"setup"}, errRun},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Execute(context.Background(), nil, tt.s)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, 期望 %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(tt.s.calls, tt.wantCalls) {
+				t.Errorf("调用顺序 = %v, 期望 %v", tt.s.calls, tt.wantCalls)
+			}
+			if len(tt.s.calls) > 1 && tt.s.ctxName != tt.s.name {
+				t.Errorf("Run 的 context 中示例名称 = %q, 期望 %q", tt.s.ctxName, tt.s.name)
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register(&fakeScenario{name: "zz-test"})
+	// 注册表是全局的，测试结束后移除，-count=N 重复执行时不会重复注册
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		delete(registry, "zz-test")
+	})
+	if _, ok := Lookup("zz-test"); !ok {
+		t.Fatal("注册后应当能查找到示例")
+	}
+	names := Names()
+	if names[len(names)-1] != "zz-test" {
+		t.Errorf("Names() = %v, 期望按名称排序", names)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("重复注册应当 panic")
+		}
+	}()
+	Register(&fakeScenario{name: "zz-test"})
+}
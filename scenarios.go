@@ -0,0 +1,10 @@
+package main
+
+// 注册所有示例到 scenario 注册表
+import (
+	_ "gorm/gormAdvanced"
+	_ "gorm/gormSql"
+	_ "gorm/gormSqlTwo"
+	_ "gorm/sqlxOne"
+	_ "gorm/sqlxTwo"
+)
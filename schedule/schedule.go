@@ -0,0 +1,224 @@
+// Package schedule 解析定时任务的执行计划，计算下一次执行时间
+//
+// 支持两种写法，时间均按 UTC 计算：
+//
+//	@every 24h       固定间隔，从上一次执行时间起算（time.ParseDuration 格式，至少 1 分钟）
+//	0 9 1 * *        五段 cron 表达式：分 时 日 月 星期，例如每月 1 日 09:00
+//
+// cron 的每一段可以是 *、数字、范围 a-b、列表 a,b 以及步长 */n、a-b/n；星期 0 和 7 都表示星期日。
+// 日和星期都不是 * 时，满足其中之一即可（与 cron 相同）。
+// 另外支持 @hourly、@daily、@weekly（星期日）、@monthly（1 日）、@yearly 简写，均在 00 分执行。
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule 执行计划
+type Schedule interface {
+	// Next 返回 t 之后（不含 t）的下一次执行时间，不会再执行时返回零值
+	Next(t time.Time) time.Time
+}
+
+var shorthands = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+	"@yearly":  "0 0 1 1 *",
+}
+
+// Parse 解析执行计划
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("无效的执行计划 %q: %w", spec, err)
+		}
+		if d < time.Minute {
+			return nil, fmt.Errorf("无效的执行计划 %q: 间隔至少 1 分钟", spec)
+		}
+		return Interval(d), nil
+	}
+	if expanded, ok := shorthands[spec]; ok {
+		spec = expanded
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("无效的执行计划 %q: cron 表达式需要 5 段（分 时 日 月 星期）", spec)
+	}
+	var c Cron
+	var err error
+	if c.minute, err = parseField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("无效的执行计划 %q: 分: %w", spec, err)
+	}
+	if c.hour, err = parseField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("无效的执行计划 %q: 时: %w", spec, err)
+	}
+	if c.dom, err = parseField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("无效的执行计划 %q: 日: %w", spec, err)
+	}
+	if c.month, err = parseField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("无效的执行计划 %q: 月: %w", spec, err)
+	}
+	if c.dow, err = parseField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("无效的执行计划 %q: 星期: %w", spec, err)
+	}
+	// 7 与 0 都表示星期日
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domAny, c.dowAny = fields[2] == "*", fields[4] == "*"
+	return c, nil
+}
+
+// Interval 固定间隔的执行计划
+type Interval time.Duration
+
+// Next 返回 t 加上间隔
+func (i Interval) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(i))
+}
+
+// Cron 五段 cron 表达式，每一段是允许取值的位集合
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+// maxSearchYears Next 最多向后查找的年数，超过时认为不会再执行（例如 2 月 30 日）
+const maxSearchYears = 5
+
+// Next 返回 t 之后第一个满足表达式的整分钟（UTC）
+func (c Cron) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxSearchYears, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case !has(c.month, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case !has(c.hour, t.Hour()):
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case !has(c.minute, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Skip 对 first（含）到 until（含）之间的执行时间只保留最后 keep 次，返回跳过的次数和保留的第一次执行时间
+// first 是一次执行时间，keep 大于 0；到期的次数不超过 keep 时 skipped 为 0，resume 为 first。
+// Interval 按间隔直接计算，长时间停机也不需要逐次查找；Cron 逐次查找。
+func Skip(s Schedule, first, until time.Time, keep int) (skipped int, resume time.Time) {
+	if i, ok := s.(Interval); ok {
+		if first.After(until) {
+			return 0, first
+		}
+		due := int(until.Sub(first)/time.Duration(i)) + 1
+		if due <= keep {
+			return 0, first
+		}
+		skipped = due - keep
+		return skipped, first.Add(time.Duration(skipped) * time.Duration(i))
+	}
+
+	// recent 保存最近 keep 次执行时间，recent[0] 最早
+	var recent []time.Time
+	for t := first; !t.IsZero() && !t.After(until); t = s.Next(t) {
+		recent = append(recent, t)
+		if len(recent) > keep {
+			recent = recent[1:]
+			skipped++
+		}
+	}
+	if skipped == 0 {
+		return 0, first
+	}
+	return skipped, recent[0]
+}
+
+// dayMatches 日和星期都有限制时满足其一即可，只有一个有限制时按该限制
+func (c Cron) dayMatches(t time.Time) bool {
+	dom, dow := has(c.dom, t.Day()), has(c.dow, int(t.Weekday()))
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+func has(set uint64, v int) bool {
+	return set&(1<<uint(v)) != 0
+}
+
+// parseField 解析 cron 的一段，返回 [min, max] 内允许取值的位集合
+func parseField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepText)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("无效的步长 %q", part)
+			}
+			step = n
+		}
+
+		lo, hi := min, max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			a, b, _ := strings.Cut(rng, "-")
+			var err error
+			if lo, err = parseValue(a, min, max); err != nil {
+				return 0, err
+			}
+			if hi, err = parseValue(b, min, max); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("无效的范围 %q", rng)
+			}
+		default:
+			v, err := parseValue(rng, min, max)
+			if err != nil {
+				return 0, err
+			}
+			// a/n 表示从 a 开始到最大值，每 n 个取一个
+			lo, hi = v, v
+			if hasStep {
+				hi = max
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+func parseValue(s string, min, max int) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("无效的取值 %q", s)
+	}
+	if v < min || v > max {
+		return 0, fmt.Errorf("取值 %d 超出范围 %d-%d", v, min, max)
+	}
+	return v, nil
+}
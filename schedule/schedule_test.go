@@ -0,0 +1,121 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// 2024-01-31 是星期三
+	from := time.Date(2024, 1, 31, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"@every 24h", time.Date(2024, 2, 1, 10, 30, 0, 0, time.UTC)},
+		{"@every 90m", time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 1, 31, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, 2, 4, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 9 1 * *", time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2024, 2, 1, 10, 30, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 31, 10, 45, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2024, 1, 31, 13, 0, 0, 0, time.UTC)},
+		{"0 0 * * 1-5", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 2, 4, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 * *", time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)},
+		// 日和星期都有限制时满足其一即可：2 月 15 日或星期五（2 月 2 日）
+		{"0 0 15 * 5", time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 3 *", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.spec)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.spec, err)
+			continue
+		}
+		if got := s.Next(from); !got.Equal(tt.want) {
+			t.Errorf("Parse(%q).Next(%s) = %s, 期望 %s", tt.spec, from, got, tt.want)
+		}
+	}
+}
+
+func TestNextNeverRuns(t *testing.T) {
+	s, err := Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Next(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)); !got.IsZero() {
+		t.Errorf("2 月 30 日的下一次执行时间 = %s, 期望零值", got)
+	}
+}
+
+func TestNextUsesUTC(t *testing.T) {
+	s, err := Parse("0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 东八区 2024-01-01 08:00 即 UTC 2024-01-01 00:00
+	from := time.Date(2024, 1, 1, 8, 0, 0, 0, time.FixedZone("CST", 8*3600))
+	if got, want := s.Next(from), time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Next = %s, 期望 %s", got, want)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"@every",
+		"@every 30s",
+		"@every abc",
+		"@fortnightly",
+		"0 9 1 *",
+		"0 9 1 * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+	} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) 应当报错", spec)
+		}
+	}
+}
+
+func TestSkip(t *testing.T) {
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		spec        string
+		until       time.Time
+		keep        int
+		wantSkipped int
+		wantResume  time.Time
+	}{
+		// 停机一天：1441 次中保留最后 3 次
+		{"@every 1m", first.Add(24 * time.Hour), 3, 1438, first.Add(24*time.Hour - 2*time.Minute)},
+		{"* * * * *", first.Add(24 * time.Hour), 3, 1438, first.Add(24*time.Hour - 2*time.Minute)},
+		// 停机十年也按间隔直接计算
+		{"@every 1m", first.AddDate(10, 0, 0), 1, 5260320, first.AddDate(10, 0, 0)},
+		// 不超过 keep 次时不跳过
+		{"@monthly", time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), 3, 0, first},
+		{"@every 1h", first.Add(-time.Minute), 1, 0, first},
+		{"@monthly", time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), 1, 2, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		skipped, resume := Skip(s, first, tt.until, tt.keep)
+		if skipped != tt.wantSkipped || !resume.Equal(tt.wantResume) {
+			t.Errorf("Skip(%q, %s, %d) = %d, %s, 期望 %d, %s", tt.spec, tt.until, tt.keep, skipped, resume, tt.wantSkipped, tt.wantResume)
+		}
+	}
+}
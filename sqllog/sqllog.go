@@ -0,0 +1,197 @@
+// Package sqllog 基于 log/slog 的 GORM 日志，为每条 SQL 输出一条结构化记录
+package sqllog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+
+	"gorm/scenario"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// 每条 SQL 记录包含以下字段：
+//   - sql          完整的 SQL（开启脱敏时字符串参数被替换为 Redacted）
+//   - rows         影响或返回的行数，-1 表示未知
+//   - duration_ms  执行耗时（毫秒）
+//   - slow         耗时是否达到慢查询阈值
+//   - caller       发起查询的代码位置（跳过 GORM 内部的调用）
+//   - scenario     正在执行的示例名称（见 scenario.NewContext）
+//   - error        执行失败时的错误
+// 失败的语句以 ERROR 级别输出，慢查询以 WARN 级别输出，其他语句以 INFO 级别输出；
+// gorm.ErrRecordNotFound 是正常的查询结果，不视为失败。
+
+// Redacted 脱敏后替代参数值的字符串
+const Redacted = "***"
+
+// Levels 日志级别名称，从安静到详细
+var Levels = []string{"silent", "error", "warn", "info"}
+
+// ParseLevel 把级别名称转换为 GORM 日志级别
+func ParseLevel(s string) (logger.LogLevel, error) {
+	switch s {
+	case "silent":
+		return logger.Silent, nil
+	case "error":
+		return logger.Error, nil
+	case "warn":
+		return logger.Warn, nil
+	case "info":
+		return logger.Info, nil
+	default:
+		return 0, fmt.Errorf("未知的 SQL 日志级别 %q（可选: %s）", s, strings.Join(Levels, "、"))
+	}
+}
+
+// Options 日志选项
+type Options struct {
+	// Level 输出的级别：Silent 不输出，Error 只输出失败的语句，Warn 增加慢查询，Info 输出全部语句
+	Level logger.LogLevel
+	// SlowThreshold 慢查询阈值，0 表示不检测慢查询
+	SlowThreshold time.Duration
+	// RedactParams 把字符串类型的绑定参数（密码、邮箱等）替换为 Redacted，数字和时间保留
+	RedactParams bool
+}
+
+// Logger 实现 logger.Interface 和 gorm.ParamsFilter
+type Logger struct {
+	log  *slog.Logger
+	opts Options
+}
+
+// New 返回输出到 l 的 GORM 日志
+func New(l *slog.Logger, opts Options) *Logger {
+	return &Logger{log: l, opts: opts}
+}
+
+// LogMode 返回使用指定级别的副本
+func (l *Logger) LogMode(level logger.LogLevel) logger.Interface {
+	c := *l
+	c.opts.Level = level
+	return &c
+}
+
+func (l *Logger) Info(ctx context.Context, msg string, data ...any) {
+	if l.opts.Level >= logger.Info {
+		l.message(ctx, slog.LevelInfo, msg, data...)
+	}
+}
+
+func (l *Logger) Warn(ctx context.Context, msg string, data ...any) {
+	if l.opts.Level >= logger.Warn {
+		l.message(ctx, slog.LevelWarn, msg, data...)
+	}
+}
+
+func (l *Logger) Error(ctx context.Context, msg string, data ...any) {
+	if l.opts.Level >= logger.Error {
+		l.message(ctx, slog.LevelError, msg, data...)
+	}
+}
+
+func (l *Logger) message(ctx context.Context, level slog.Level, msg string, data ...any) {
+	attrs := []slog.Attr{slog.String("caller", caller())}
+	if name := scenario.NameFromContext(ctx); name != "" {
+		attrs = append(attrs, slog.String("scenario", name))
+	}
+	l.log.LogAttrs(ctx, level, fmt.Sprintf(msg, data...), attrs...)
+}
+
+// Trace 输出一条 SQL 记录
+func (l *Logger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.opts.Level <= logger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	failed := err != nil && !errors.Is(err, gorm.ErrRecordNotFound)
+	slow := l.opts.SlowThreshold > 0 && elapsed >= l.opts.SlowThreshold
+
+	var level slog.Level
+	var msg string
+	switch {
+	case failed && l.opts.Level >= logger.Error:
+		level, msg = slog.LevelError, "SQL 执行失败"
+	case slow && l.opts.Level >= logger.Warn:
+		level, msg = slog.LevelWarn, "慢查询"
+	case l.opts.Level >= logger.Info:
+		level, msg = slog.LevelInfo, "SQL"
+	default:
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("sql", sql),
+		slog.Int64("rows", rows),
+		slog.Float64("duration_ms", float64(elapsed.Microseconds())/1000),
+		slog.Bool("slow", slow),
+		slog.String("caller", caller()),
+	}
+	if name := scenario.NameFromContext(ctx); name != "" {
+		attrs = append(attrs, slog.String("scenario", name))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	l.log.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// ParamsFilter 在生成日志中的 SQL 前处理绑定参数，开启脱敏时替换字符串参数
+func (l *Logger) ParamsFilter(ctx context.Context, sql string, params ...any) (string, []any) {
+	if !l.opts.RedactParams {
+		return sql, params
+	}
+	redacted := make([]any, len(params))
+	for i, p := range params {
+		redacted[i] = redact(p)
+	}
+	return sql, redacted
+}
+
+// redact 替换字符串和字节切片参数（包括指向它们的指针），其他类型原样返回
+func redact(p any) any {
+	v := reflect.ValueOf(p)
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	switch {
+	case v.Kind() == reflect.String:
+		return Redacted
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		return Redacted
+	}
+	return p
+}
+
+// caller 返回调用栈中第一个不属于 GORM、数据库驱动和本包的位置
+func caller() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		if !skipFrame(f.Function) {
+			return filepath.Base(filepath.Dir(f.File)) + "/" + filepath.Base(f.File) + fmt.Sprintf(":%d", f.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+func skipFrame(function string) bool {
+	for _, prefix := range []string{"gorm.io/", "gorm/sqllog.", "database/sql.", "runtime."} {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package sqllog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm/internal/testdb"
+	"gorm/scenario"
+	"gorm/sqllog"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type account struct {
+	ID    uint
+	Email string
+	Age   int
+}
+
+// capture 返回使用 sqllog 的数据库会话和读取已输出记录的函数
+func capture(t *testing.T, opts sqllog.Options) (*gorm.DB, func() []map[string]any) {
+	t.Helper()
+	db := testdb.Open(t)
+	if err := db.AutoMigrate(&account{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	l := sqllog.New(slog.New(slog.NewJSONHandler(&buf, nil)), opts)
+	records := func() []map[string]any {
+		var list []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			var r map[string]any
+			if err := json.Unmarshal([]byte(line), &r); err != nil {
+				t.Fatalf("日志不是 JSON: %s", line)
+			}
+			list = append(list, r)
+		}
+		buf.Reset()
+		return list
+	}
+	return db.Session(&gorm.Session{Logger: l}), records
+}
+
+func TestTrace(t *testing.T) {
+	db, records := capture(t, sqllog.Options{Level: logger.Info})
+	ctx := scenario.NewContext(context.Background(), "demo")
+
+	if err := db.WithContext(ctx).Create(&account{Email: "a@example.com", Age: 30}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	list := records()
+	if len(list) != 1 {
+		t.Fatalf("记录数 = %d, 期望 1: %v", len(list), list)
+	}
+	r := list[0]
+	if r["level"] != "INFO" || r["rows"] != float64(1) || r["scenario"] != "demo" || r["slow"] != false {
+		t.Errorf("记录 = %v", r)
+	}
+	if sql, _ := r["sql"].(string); !strings.Contains(sql, "INSERT INTO `accounts`") || !strings.Contains(sql, "a@example.com") {
+		t.Errorf("sql = %q", sql)
+	}
+	if caller, _ := r["caller"].(string); !strings.HasPrefix(caller, "sqllog/sqllog_test.go:") {
+		t.Errorf("caller = %q, 期望指向测试代码", caller)
+	}
+	if _, ok := r["duration_ms"].(float64); !ok {
+		t.Errorf("缺少 duration_ms: %v", r)
+	}
+}
+
+func TestTraceLevels(t *testing.T) {
+	db, records := capture(t, sqllog.Options{Level: logger.Warn})
+
+	// Warn 级别不输出成功的语句；记录不存在不视为失败
+	var a account
+	db.Create(&account{Email: "b@example.com"})
+	db.First(&a, 999)
+	if list := records(); len(list) != 0 {
+		t.Errorf("Warn 级别输出了 %d 条记录: %v", len(list), list)
+	}
+
+	db.Exec("SELECT * FROM missing_table")
+	list := records()
+	if len(list) != 1 || list[0]["level"] != "ERROR" || list[0]["error"] == nil {
+		t.Errorf("失败的语句 = %v, 期望一条带 error 的 ERROR 记录", list)
+	}
+
+	db.Logger = db.Logger.LogMode(logger.Silent)
+	db.Exec("SELECT * FROM missing_table")
+	if list := records(); len(list) != 0 {
+		t.Errorf("Silent 级别输出了 %d 条记录", len(list))
+	}
+}
+
+func TestTraceSlowQuery(t *testing.T) {
+	db, records := capture(t, sqllog.Options{Level: logger.Warn, SlowThreshold: time.Nanosecond})
+
+	var n int64
+	db.Model(&account{}).Count(&n)
+	list := records()
+	if len(list) != 1 || list[0]["level"] != "WARN" || list[0]["slow"] != true {
+		t.Errorf("慢查询记录 = %v", list)
+	}
+}
+
+func TestRedactParams(t *testing.T) {
+	db, records := capture(t, sqllog.Options{Level: logger.Info, RedactParams: true})
+
+	email := "secret@example.com"
+	db.Where("email = ? AND age > ?", &email, 18).Find(&[]account{})
+	list := records()
+	if len(list) != 1 {
+		t.Fatalf("记录数 = %d", len(list))
+	}
+	sql, _ := list[0]["sql"].(string)
+	if strings.Contains(sql, email) {
+		t.Errorf("sql 中包含未脱敏的邮箱: %s", sql)
+	}
+	if !strings.Contains(sql, sqllog.Redacted) || !strings.Contains(sql, "18") {
+		t.Errorf("sql = %s, 期望替换字符串参数并保留数字参数", sql)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for i, name := range sqllog.Levels {
+		level, err := sqllog.ParseLevel(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if level != logger.LogLevel(i+1) {
+			t.Errorf("ParseLevel(%s) = %d", name, level)
+		}
+	}
+	if _, err := sqllog.ParseLevel("debug"); err == nil {
+		t.Error("未知级别应当报错")
+	}
+}
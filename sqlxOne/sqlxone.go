@@ -1,8 +1,14 @@
 package sqlxone
 
 import (
+	"context"
 	"fmt"
-	"log"
+
+	"gorm/database"
+	"gorm/fixtures"
+	"gorm/migrate"
+	"gorm/presenter"
+	"gorm/scenario"
 
 	"github.com/jmoiron/sqlx"
 	"gorm.io/gorm"
@@ -16,114 +22,107 @@ import (
 
 // Employee 员工结构体
 type Employee struct {
-	ID         int     `db:"id"`
-	Name       string  `db:"name"`
-	Department string  `db:"department"`
-	Salary     float64 `db:"salary"`
+	ID         int     `db:"id" json:"id" label:"ID"`
+	Name       string  `db:"name" json:"name" label:"姓名"`
+	Department string  `db:"department" json:"department" label:"部门"`
+	Salary     float64 `db:"salary" json:"salary" label:"工资"`
+}
+
+// TechDepartment 技术部
+const TechDepartment = "技术部"
+
+func init() {
+	fixtures.Register("employees", func() any { return &Employee{} })
+	scenario.Register(&Scenario{})
 }
 
+// Scenario Sqlx 员工查询示例
+type Scenario struct{}
+
+func (*Scenario) Name() string        { return "employees" }
+func (*Scenario) Description() string { return "Sqlx 题目1：员工查询" }
+
+// Setup 执行数据库迁移确保 employees 表存在，并加载测试数据
+func (*Scenario) Setup(ctx context.Context, db *gorm.DB) error {
+	if _, err := migrate.Up(ctx, db); err != nil {
+		return err
+	}
+
+	return initEmployeesTable(ctx, db)
+}
+
+func (*Scenario) Teardown(context.Context, *gorm.DB) error { return nil }
+
 // Run 执行sqlx查询示例
-func Run(db *gorm.DB) {
+func (*Scenario) Run(ctx context.Context, db *gorm.DB) error {
 	fmt.Println("\n========== 开始执行 Sqlx 查询示例 ==========")
 
-	// 从 GORM DB 获取底层的 *sql.DB
-	sqlDB, err := db.DB()
+	sqlxDB, err := database.NewSqlx(db)
 	if err != nil {
-		log.Printf("获取 sql.DB 失败: %v\n", err)
-		return
+		return err
 	}
 
-	// 使用 sqlx 包装 sql.DB
-	sqlxDB := sqlx.NewDb(sqlDB, "mysql")
-
-	// 确保 employees 表存在并初始化数据
-	initEmployeesTable(sqlxDB)
+	p := presenter.FromContext(ctx)
 
 	// 1. 查询技术部的所有员工
-	queryTechDepartment(sqlxDB)
+	employees, err := QueryByDepartment(ctx, sqlxDB, TechDepartment)
+	if err != nil {
+		return err
+	}
+	if err := p.Print(fmt.Sprintf("查询到 %d 名技术部员工", len(employees)), employees); err != nil {
+		return err
+	}
 
 	// 2. 查询工资最高的员工
-	queryHighestSalary(sqlxDB)
+	employee, err := QueryHighestSalary(ctx, sqlxDB)
+	if err != nil {
+		return err
+	}
+	if err := p.Print("工资最高的员工", employee); err != nil {
+		return err
+	}
 
 	fmt.Println("========== Sqlx 查询示例执行完毕 ==========")
+	return nil
 }
 
-// initEmployeesTable 初始化员工表和数据
-func initEmployeesTable(db *sqlx.DB) {
+// initEmployeesTable 写入测试数据，employees 表已有数据时保留原数据；指定 -reset 时先清空表
+func initEmployeesTable(ctx context.Context, db *gorm.DB) error {
 	fmt.Println("\n--- 初始化 employees 表 ---")
 
-	// 创建表
-	createTableSQL := `
-	CREATE TABLE IF NOT EXISTS employees (
-		id INT AUTO_INCREMENT PRIMARY KEY,
-		name VARCHAR(100) NOT NULL,
-		department VARCHAR(100) NOT NULL,
-		salary DECIMAL(10, 2) NOT NULL
-	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
-	`
-	_, err := db.Exec(createTableSQL)
-	if err != nil {
-		log.Printf("创建 employees 表失败: %v\n", err)
-		return
-	}
-
-	// 清空表
-	_, err = db.Exec("TRUNCATE TABLE employees")
-	if err != nil {
-		log.Printf("清空 employees 表失败: %v\n", err)
+	opts := fixtures.FromContext(ctx)
+	if opts.Reset {
+		if err := fixtures.Reset(ctx, db, "employees"); err != nil {
+			return err
+		}
+		fmt.Println("已清空 employees 表")
 	}
 
-	// 插入测试数据
-	insertSQL := `
-	INSERT INTO employees (name, department, salary) VALUES
-		('张三', '技术部', 8000.00),
-		('李四', '技术部', 9500.00),
-		('王五', '销售部', 7000.00),
-		('赵六', '技术部', 12000.00),
-		('钱七', '人力资源部', 6500.00),
-		('孙八', '技术部', 10500.00),
-		('周九', '销售部', 8500.00);
-	`
-	_, err = db.Exec(insertSQL)
+	result, err := fixtures.Load(ctx, db, opts.Set, "employees")
 	if err != nil {
-		log.Printf("插入测试数据失败: %v\n", err)
-		return
+		return err
 	}
 
-	fmt.Println("employees 表初始化成功，已插入测试数据")
+	fmt.Printf("employees 表初始化成功，测试数据集 %s: %s\n", opts.Set, result.Summary("employees"))
+	return nil
 }
 
-// queryTechDepartment 查询技术部的所有员工
-func queryTechDepartment(db *sqlx.DB) {
-	fmt.Println("\n--- 查询技术部的所有员工 ---")
-
+// QueryByDepartment 查询指定部门的所有员工
+func QueryByDepartment(ctx context.Context, db *sqlx.DB, department string) ([]Employee, error) {
 	var employees []Employee
-	query := "SELECT id, name, department, salary FROM employees WHERE department = ?"
-	err := db.Select(&employees, query, "技术部")
-	if err != nil {
-		log.Printf("查询技术部员工失败: %v\n", err)
-		return
-	}
-
-	fmt.Printf("查询到 %d 名技术部员工:\n", len(employees))
-	for _, emp := range employees {
-		fmt.Printf("ID: %d, 姓名: %s, 部门: %s, 工资: %.2f\n",
-			emp.ID, emp.Name, emp.Department, emp.Salary)
+	query := db.Rebind("SELECT id, name, department, salary FROM employees WHERE department = ?")
+	if err := db.SelectContext(ctx, &employees, query, department); err != nil {
+		return nil, fmt.Errorf("查询%s员工失败: %w", department, err)
 	}
+	return employees, nil
 }
 
-// queryHighestSalary 查询工资最高的员工
-func queryHighestSalary(db *sqlx.DB) {
-	fmt.Println("\n--- 查询工资最高的员工 ---")
-
+// QueryHighestSalary 查询工资最高的员工
+func QueryHighestSalary(ctx context.Context, db *sqlx.DB) (*Employee, error) {
 	var employee Employee
 	query := "SELECT id, name, department, salary FROM employees ORDER BY salary DESC LIMIT 1"
-	err := db.Get(&employee, query)
-	if err != nil {
-		log.Printf("查询工资最高的员工失败: %v\n", err)
-		return
+	if err := db.GetContext(ctx, &employee, query); err != nil {
+		return nil, fmt.Errorf("查询工资最高的员工失败: %w", err)
 	}
-
-	fmt.Printf("工资最高的员工:\nID: %d, 姓名: %s, 部门: %s, 工资: %.2f\n",
-		employee.ID, employee.Name, employee.Department, employee.Salary)
+	return &employee, nil
 }
@@ -0,0 +1,116 @@
+package sqlxone
+
+import (
+	"context"
+	"testing"
+
+	"gorm/database"
+	"gorm/fixtures"
+	"gorm/internal/testdb"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// openEmployees 通过 Scenario.Setup 在内存 SQLite 中创建 employees 表并写入测试数据
+func openEmployees(t *testing.T) *sqlx.DB {
+	t.Helper()
+	gdb := testdb.Open(t)
+	if err := (&Scenario{}).Setup(testdb.Context(), gdb); err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	db, err := database.NewSqlx(gdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestQueryByDepartment(t *testing.T) {
+	db := openEmployees(t)
+
+	employees, err := QueryByDepartment(context.Background(), db, TechDepartment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(employees) != 2 {
+		t.Fatalf("技术部员工数 = %d, 期望 2", len(employees))
+	}
+	for _, e := range employees {
+		if e.Department != TechDepartment {
+			t.Errorf("员工 %s 的部门 = %s", e.Name, e.Department)
+		}
+	}
+
+	employees, err = QueryByDepartment(context.Background(), db, "财务部")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(employees) != 0 {
+		t.Errorf("财务部员工数 = %d, 期望 0", len(employees))
+	}
+}
+
+func TestQueryHighestSalary(t *testing.T) {
+	db := openEmployees(t)
+
+	e, err := QueryHighestSalary(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Name != "赵六" || e.Salary != 12000 {
+		t.Errorf("工资最高的员工 = %+v, 期望 赵六 12000.00", e)
+	}
+}
+
+func TestScenario(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := testdb.Context()
+	s := &Scenario{}
+
+	// Setup 可以重复执行
+	for i := 0; i < 2; i++ {
+		if err := s.Setup(ctx, db); err != nil {
+			t.Fatalf("Setup #%d: %v", i+1, err)
+		}
+	}
+	if err := s.Run(ctx, db); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+}
+
+func TestSetupKeepsExistingData(t *testing.T) {
+	gdb := testdb.Open(t)
+	ctx := testdb.Context()
+	s := &Scenario{}
+	count := func() int64 {
+		var n int64
+		if err := gdb.Model(&Employee{}).Count(&n).Error; err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	if err := s.Setup(ctx, gdb); err != nil {
+		t.Fatal(err)
+	}
+	if err := gdb.Create(&Employee{Name: "新员工", Department: "财务部", Salary: 6000}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// employees 表已有数据，再次 Setup 不会清空也不会重复写入
+	if err := s.Setup(ctx, gdb); err != nil {
+		t.Fatal(err)
+	}
+	if got := count(); got != 5 {
+		t.Errorf("再次 Setup 后员工数 = %d, 期望 5", got)
+	}
+
+	// 指定 Reset 时清空后重新写入
+	resetCtx := fixtures.NewContext(ctx, fixtures.Options{Set: fixtures.SetTest, Reset: true})
+	if err := s.Setup(resetCtx, gdb); err != nil {
+		t.Fatal(err)
+	}
+	if got := count(); got != 4 {
+		t.Errorf("Reset 后员工数 = %d, 期望 4", got)
+	}
+}
@@ -1,8 +1,15 @@
 package sqlxtwo
 
 import (
+	"context"
+	"flag"
 	"fmt"
-	"log"
+
+	"gorm/database"
+	"gorm/fixtures"
+	"gorm/migrate"
+	"gorm/presenter"
+	"gorm/scenario"
 
 	"github.com/jmoiron/sqlx"
 	"gorm.io/gorm"
@@ -15,96 +22,87 @@ import (
 
 // Book 书籍结构体
 type Book struct {
-	ID     int     `db:"id"`
-	Title  string  `db:"title"`
-	Author string  `db:"author"`
-	Price  float64 `db:"price"`
+	ID     int     `db:"id" json:"id" label:"ID"`
+	Title  string  `db:"title" json:"title" label:"书名"`
+	Author string  `db:"author" json:"author" label:"作者"`
+	Price  float64 `db:"price" json:"price" label:"价格"`
 }
 
-// Run 执行sqlx查询示例
-func Run(db *gorm.DB) {
-	fmt.Println("\n========== 开始执行 Sqlx Books 查询示例 ==========")
+func init() {
+	fixtures.Register("books", func() any { return &Book{} }, "title", "author")
+	scenario.Register(&Scenario{MinPrice: 50})
+}
 
-	// 从 GORM DB 获取底层的 *sql.DB
-	sqlDB, err := db.DB()
-	if err != nil {
-		log.Printf("获取 sql.DB 失败: %v\n", err)
-		return
-	}
+// Scenario Sqlx 书籍查询示例
+type Scenario struct {
+	// MinPrice 查询的最低价格
+	MinPrice float64
+}
+
+func (*Scenario) Name() string        { return "books" }
+func (*Scenario) Description() string { return "Sqlx 题目2：书籍查询" }
 
-	// 使用 sqlx 包装 sql.DB
-	sqlxDB := sqlx.NewDb(sqlDB, "mysql")
+// BindFlags 注册最低价格参数
+func (s *Scenario) BindFlags(fs *flag.FlagSet) {
+	fs.Float64Var(&s.MinPrice, "min-price", s.MinPrice, "books: 查询的最低价格")
+}
 
-	// 确保 books 表存在并初始化数据
-	initBooksTable(sqlxDB)
+// Setup 执行数据库迁移确保 books 表存在，并加载测试数据
+func (*Scenario) Setup(ctx context.Context, db *gorm.DB) error {
+	if _, err := migrate.Up(ctx, db); err != nil {
+		return err
+	}
 
-	// 查询价格大于 50 元的书籍
-	queryExpensiveBooks(sqlxDB, 50.0)
+	return initBooksTable(ctx, db)
 }
 
-// initBooksTable 初始化书籍表和数据
-func initBooksTable(db *sqlx.DB) {
-	fmt.Println("\n--- 初始化 books 表 ---")
+func (*Scenario) Teardown(context.Context, *gorm.DB) error { return nil }
 
-	// 创建表
-	createTableSQL := `
-	CREATE TABLE IF NOT EXISTS books (
-		id INT AUTO_INCREMENT PRIMARY KEY,
-		title VARCHAR(200) NOT NULL,
-		author VARCHAR(100) NOT NULL,
-		price DECIMAL(10, 2) NOT NULL
-	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
-	`
-	_, err := db.Exec(createTableSQL)
-	if err != nil {
-		log.Printf("创建 books 表失败: %v\n", err)
-		return
-	}
+// Run 执行sqlx查询示例
+func (s *Scenario) Run(ctx context.Context, db *gorm.DB) error {
+	fmt.Println("\n========== 开始执行 Sqlx Books 查询示例 ==========")
 
-	// 清空表
-	_, err = db.Exec("TRUNCATE TABLE books")
+	sqlxDB, err := database.NewSqlx(db)
 	if err != nil {
-		log.Printf("清空 books 表失败: %v\n", err)
+		return err
 	}
 
-	// 插入测试数据
-	insertSQL := `
-	INSERT INTO books (title, author, price) VALUES
-		('射雕英雄传', '金庸', 68.00),
-		('天龙八部', '金庸', 78.50),
-		('三体', '刘慈欣', 45.00),
-		('活着', '余华', 32.00),
-		('白夜行', '东野圭吾', 55.00),
-		('解忧杂货店', '东野圭吾', 42.00),
-		('红楼梦', '曹雪芹', 89.00),
-		('平凡的世界', '路遥', 38.00),
-		('百年孤独', '加西亚·马尔克斯', 52.00),
-		('小王子', '圣埃克苏佩里', 28.00);
-	`
-	_, err = db.Exec(insertSQL)
+	// 查询价格大于 MinPrice 的书籍
+	books, err := QueryExpensiveBooks(ctx, sqlxDB, s.MinPrice)
 	if err != nil {
-		log.Printf("插入测试数据失败: %v\n", err)
-		return
+		return err
 	}
-
-	fmt.Println("books 表初始化成功，已插入测试数据")
+	title := fmt.Sprintf("查询到 %d 本价格大于 %.2f 元的书籍", len(books), s.MinPrice)
+	return presenter.FromContext(ctx).Print(title, books)
 }
 
-// queryExpensiveBooks 查询价格大于指定金额的书籍
-func queryExpensiveBooks(db *sqlx.DB, minPrice float64) {
-	fmt.Printf("\n--- 查询价格大于 %.2f 元的书籍 ---\n", minPrice)
+// initBooksTable 写入测试数据，books 表已有数据时保留原数据；指定 -reset 时先清空表
+func initBooksTable(ctx context.Context, db *gorm.DB) error {
+	fmt.Println("\n--- 初始化 books 表 ---")
 
-	var books []Book
-	query := "SELECT id, title, author, price FROM books WHERE price > ? ORDER BY price DESC"
-	err := db.Select(&books, query, minPrice)
+	opts := fixtures.FromContext(ctx)
+	if opts.Reset {
+		if err := fixtures.Reset(ctx, db, "books"); err != nil {
+			return err
+		}
+		fmt.Println("已清空 books 表")
+	}
+
+	result, err := fixtures.Load(ctx, db, opts.Set, "books")
 	if err != nil {
-		log.Printf("查询书籍失败: %v\n", err)
-		return
+		return err
 	}
 
-	fmt.Printf("查询到 %d 本价格大于 %.2f 元的书籍:\n", len(books), minPrice)
-	for _, book := range books {
-		fmt.Printf("ID: %d, 书名: %s, 作者: %s, 价格: %.2f 元\n",
-			book.ID, book.Title, book.Author, book.Price)
+	fmt.Printf("books 表初始化成功，测试数据集 %s: %s\n", opts.Set, result.Summary("books"))
+	return nil
+}
+
+// QueryExpensiveBooks 查询价格大于指定金额的书籍，按价格从高到低排序
+func QueryExpensiveBooks(ctx context.Context, db *sqlx.DB, minPrice float64) ([]Book, error) {
+	var books []Book
+	query := db.Rebind("SELECT id, title, author, price FROM books WHERE price > ? ORDER BY price DESC")
+	if err := db.SelectContext(ctx, &books, query, minPrice); err != nil {
+		return nil, fmt.Errorf("查询书籍失败: %w", err)
 	}
+	return books, nil
 }
@@ -0,0 +1,110 @@
+package sqlxtwo
+
+import (
+	"context"
+	"testing"
+
+	"gorm/database"
+	"gorm/internal/testdb"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// openBooks 通过 Scenario.Setup 在内存 SQLite 中创建 books 表并写入测试数据
+func openBooks(t *testing.T) *sqlx.DB {
+	t.Helper()
+	gdb := testdb.Open(t)
+	if err := (&Scenario{}).Setup(testdb.Context(), gdb); err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	db, err := database.NewSqlx(gdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestQueryExpensiveBooks(t *testing.T) {
+	db := openBooks(t)
+
+	books, err := QueryExpensiveBooks(context.Background(), db, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 价格正好是 50 的三体不在结果中
+	want := []string{"天龙八部", "白夜行"}
+	if len(books) != len(want) {
+		t.Fatalf("查询到 %d 本书, 期望 %d 本: %+v", len(books), len(want), books)
+	}
+	for i, b := range books {
+		if b.Title != want[i] {
+			t.Errorf("第 %d 本书 = %s, 期望 %s（按价格降序）", i+1, b.Title, want[i])
+		}
+		if b.Price <= 50 {
+			t.Errorf("%s 的价格 %.2f 不大于 50", b.Title, b.Price)
+		}
+	}
+}
+
+func TestQueryExpensiveBooksNoMatch(t *testing.T) {
+	db := openBooks(t)
+
+	books, err := QueryExpensiveBooks(context.Background(), db, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(books) != 0 {
+		t.Errorf("查询到 %d 本书, 期望 0", len(books))
+	}
+}
+
+func TestScenario(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := testdb.Context()
+	s := &Scenario{MinPrice: 60}
+
+	// Setup 可以重复执行
+	for i := 0; i < 2; i++ {
+		if err := s.Setup(ctx, db); err != nil {
+			t.Fatalf("Setup #%d: %v", i+1, err)
+		}
+	}
+	if err := s.Run(ctx, db); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+}
+
+func TestSetupUpsertsByTitleAndAuthor(t *testing.T) {
+	gdb := testdb.Open(t)
+	ctx := testdb.Context()
+	s := &Scenario{}
+
+	if err := s.Setup(ctx, gdb); err != nil {
+		t.Fatal(err)
+	}
+	extra := Book{Title: "新书", Author: "新作者", Price: 10}
+	if err := gdb.Create(&extra).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := gdb.Model(&Book{}).Where("title = ?", "天龙八部").Update("price", 1).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// 再次 Setup：测试数据中的图书按书名和作者更新，其他图书保留
+	if err := s.Setup(ctx, gdb); err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	gdb.Model(&Book{}).Count(&count)
+	if count != 5 {
+		t.Errorf("再次 Setup 后图书数 = %d, 期望 5", count)
+	}
+	var book Book
+	if err := gdb.Where("title = ?", "天龙八部").First(&book).Error; err != nil {
+		t.Fatal(err)
+	}
+	if book.Price != 78.5 {
+		t.Errorf("天龙八部 的价格 = %v, 期望恢复为 78.5", book.Price)
+	}
+}